@@ -2,36 +2,71 @@ package main
 
 import (
 	"embed"
+	"flag"
 	"fmt"
 	"log"
+	"math/rand/v2"
 	"os"
 	"path/filepath"
-	"strconv"
 	"strings"
-	"time"
 
 	"github.com/BurntSushi/toml"
+	"github.com/TH3-F001/GoChip-8/chip8/internal/capture"
 	"github.com/TH3-F001/GoChip-8/chip8/internal/chip8"
 	"github.com/TH3-F001/GoChip-8/chip8/internal/config"
+	"github.com/TH3-F001/GoChip-8/chip8/internal/fonts"
 	"github.com/TH3-F001/GoChip-8/chip8/internal/io"
+	"github.com/TH3-F001/GoChip-8/chip8/internal/io/headlessio"
+	"github.com/TH3-F001/GoChip-8/chip8/internal/movie"
+	"github.com/TH3-F001/GoChip-8/chip8/internal/present"
+	"github.com/TH3-F001/GoChip-8/chip8/internal/runner"
+	"github.com/TH3-F001/GoChip-8/chip8/internal/savestate"
+	"github.com/TH3-F001/GoChip-8/chip8/internal/termgfx"
 
-	// "github.com/TH3-F001/GoChip-8/chip8/internal/io/sdlio"
 	"github.com/TH3-F001/GoChip-8/chip8/internal/io/tcellio"
-	// "github.com/TH3-F001/GoChip-8/chip8/internal/io/vanillaio"
+	"github.com/TH3-F001/GoChip-8/chip8/internal/io/vanillaio"
+	"github.com/TH3-F001/GoChip-8/chip8/internal/io/webio"
 )
 
 //go:embed config/chip8.toml
 var embeddedConf embed.FS
 
-//go:embed fonts/*
-var fonts embed.FS
-
 //go:embed demo/*
 var demoProgs embed.FS
 
 // terminationCh ... A go channel used by io.ListenForTerminate() to listen for user termination
 var terminationCh chan bool = make(chan bool)
 
+// hotkeyCh ... A go channel that io backends implementing io.HotkeyIO send hotkeys to
+var hotkeyCh chan io.Hotkey = make(chan io.Hotkey, 8)
+
+// seedFlag ... Overrides the config's RandomSeed when non-zero
+var seedFlag = flag.Uint64("seed", 0, "seed for the RND instruction's random number generator (0 uses the config's RandomSeed)")
+
+// recordFlag, playFlag and verifyFlag ... Paths of a movie file to record keypresses to, play keypresses back from, or verify without a display
+var (
+	recordFlag = flag.String("record", "", "record every keypress to the given movie file. rewinding and loading save states are disabled while recording")
+	playFlag   = flag.String("play", "", "play back the keypresses in the given movie file. rewinding and loading save states are disabled during playback")
+	verifyFlag = flag.String("verify", "", "play back the given movie file without a display, and check that it ends on the recorded display")
+)
+
+// headlessFlag, scriptFlag and framesFlag ... Run without a display, driving the keypad from a script, and stop after a number of frames
+var (
+	headlessFlag = flag.Bool("headless", false, "run without a display as fast as possible, the same as IOType = \"headless\"")
+	scriptFlag   = flag.String("script", "", "press keys from the given script when running without a display")
+	framesFlag   = flag.Uint64("frames", 0, "stop after the given number of frames (0 runs until the program exits)")
+)
+
+// screenshotFlag ... Runs the program without a display for this many frames, then saves a screenshot and exits
+var screenshotFlag = flag.Uint64("screenshot", 0, "run the program without a display for the given number of frames, then save a screenshot and exit")
+
+// captureFlag, captureFormatFlag and captureFramesFlag ... Where to record gameplay to, in what format, and which frames to record without a display
+var (
+	captureFlag       = flag.String("capture", "", "record gameplay to the given file, or - for standard output")
+	captureFormatFlag = flag.String("capture-format", "", "format to record in: gif, ppm or y4m (defaults to the -capture file's extension, then the config's CaptureFormat)")
+	captureFramesFlag = flag.String("capture-frames", "", "run the program without a display, record frames START:STOP to the -capture file, and exit")
+)
+
 // #region Configuration
 func getConfigPath() string {
 	configPath := ""
@@ -43,12 +78,7 @@ func getConfigPath() string {
 		}
 	}
 
-	configDir, err := os.UserConfigDir()
-	if err != nil {
-		log.Fatal("Fatal: Cannot find user config directory: ", err)
-	}
-
-	configPath = filepath.Join(configDir, "GoChip-8", "chip8.toml")
+	configPath = filepath.Join(getConfigDir(), "chip8.toml")
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
 		//  If all else fails, fall back on the embedded config file and build a new config dir/file
 		buildConfigDirectory()
@@ -56,13 +86,17 @@ func getConfigPath() string {
 	return configPath
 }
 
-func buildConfigDirectory() string {
+// getConfigDir ... Returns the GoChip-8 directory inside the user's config directory
+func getConfigDir() string {
 	configDir, err := os.UserConfigDir()
 	if err != nil {
 		log.Fatal("Fatal: Cannot find user config directory: ", err)
 	}
-	configDir = filepath.Join(configDir, "GoChip-8")
-	configPath := filepath.Join(configDir, "chip8.toml")
+	return filepath.Join(configDir, "GoChip-8")
+}
+
+func buildConfigDirectory() string {
+	configPath := filepath.Join(getConfigDir(), "chip8.toml")
 	defaultConfig, err := embeddedConf.ReadFile("config/chip8.toml")
 	if err != nil {
 		log.Fatal("Fatal: Failed to load embedded chip8.toml file")
@@ -94,48 +128,19 @@ func loadConfig(path string) config.Config {
 
 // #region Initialization
 func getDefaultFont(config config.Config) []byte {
-	var rawFontData []byte
-	var err error = nil
-	switch config.DefaultFont {
-	case "chip48":
-		rawFontData, err = fonts.ReadFile("fonts/chip48font.txt")
-	case "cosmac":
-		rawFontData, err = fonts.ReadFile("fonts/cosmacvipfont.txt")
-	case "dream":
-		rawFontData, err = fonts.ReadFile("fonts/dream6800font.txt")
-	case "eti":
-		rawFontData, err = fonts.ReadFile("fonts/eti660font.txt")
-	default:
-		rawFontData, err = fonts.ReadFile("fonts/chip48font.txt")
-	}
+	font, err := fonts.Small(config.DefaultFont)
 	if err != nil {
 		log.Fatal("Fatal: Failed to load default font file: ", err)
 	}
+	return font
+}
 
-	fontString := string(rawFontData)
-	fontStringArr := make([]string, 0)
-
-	segments := strings.Split(fontString, "0x")
-	for _, seg := range segments {
-		lines := strings.Split(seg, "\n")
-		for _, line := range lines {
-			if !strings.Contains(line, ":") && line != "" {
-				str := strings.TrimSuffix(strings.TrimPrefix(line, "0b"), "\r")
-				fontStringArr = append(fontStringArr, str)
-			}
-		}
-	}
-
-	fontBytes := make([]byte, 0)
-	for _, str := range fontStringArr {
-		val, err := strconv.ParseUint(str, 2, 8)
-		if err != nil {
-			log.Fatal("Fatal: couldnt convert font to binary: ", err)
-		}
-		fontBytes = append(fontBytes, byte(val))
+func getBigFont() []byte {
+	font, err := fonts.Big()
+	if err != nil {
+		log.Fatal("Fatal: Failed to load big font file: ", err)
 	}
-
-	return fontBytes
+	return font
 }
 
 func getProgram(conf config.Config) []byte {
@@ -146,91 +151,451 @@ func getProgram(conf config.Config) []byte {
 		if err != nil {
 			log.Fatal("Fatal: Failed to load default program file: ", err)
 		}
+	} else {
+		rawProgramData, err = os.ReadFile(conf.ProgramPath)
+		if err != nil {
+			log.Fatal("Fatal: Failed to load program file: ", err)
+		}
 	}
 	return rawProgramData
 }
 
-func createIo(conf config.Config) (io.IO, byte, byte, error) {
-	var dw int
-	var dh int
-	if conf.CosmacCompatible {
-		dw = 64
-		dh = 32
-	} else {
-		dw = 128
-		dh = 64
+// getProgramName ... Returns the path of the program being run, used to name its save states
+func getProgramName(conf config.Config) string {
+	if conf.ProgramPath == "" {
+		return "IBM_Logo.ch8"
+	}
+	return conf.ProgramPath
+}
+
+// getDisplaySize ... Returns the height and width of the display. SUPER-CHIP's high resolution mode needs twice the classic 64x32
+func getDisplaySize(quirks config.Quirks) (int, int) {
+	if quirks.SuperChip {
+		return 64, 128
 	}
+	return 32, 64
+}
+
+// getTermGraphics ... Returns the graphics protocol VanillaIO draws bitmaps with for an IOType. sixel and kitty force their protocol,
+// graphics and bitmap use whichever the terminal supports, and the rest only draw characters
+func getTermGraphics(ioType string) termgfx.Protocol {
+	switch ioType {
+	case "graphics", "bitmap":
+		return termgfx.Detect
+	case "sixel":
+		return termgfx.Sixel
+	case "kitty":
+		return termgfx.Kitty
+	}
+	return termgfx.None
+}
+
+func createIo(conf config.Config, quirks config.Quirks) (io.IO, byte, byte, error) {
+	dh, dw := getDisplaySize(quirks)
 
-	var io io.IO
+	var inout io.IO
 	var err error
 	switch conf.IOType {
 	case "tcellio", "tcell", "tui":
-		io, err = tcellio.New(dh, dw, conf.FgColor, conf.BgColor)
+		inout, err = tcellio.New(conf.FgColor, conf.BgColor, conf.Palette, conf.Renderer, conf.Scale, conf.Persistence)
 		if err != nil {
 			log.Fatal("Fatal: Failed to Create new TcellIO instance: ", err)
 		}
-	// case "vanilla", "terminal", "term":
-	// 	io, err = vanillaio.New(dw, dh, conf.FgColor, conf.BgColor)
-	// 	if err != nil {
-	// 		log.Fatal("Fatal: Failed to Create new VanillaIO instance: ", err)
-	// 	}
-	// case "sdl", "graphical", "gui":
-	// 	io, err = sdlio.New(dw, dh, conf.FgColor, conf.BgColor)
-	// 	if err != nil {
-	// 		log.Fatal("Fatal: Failed to Create new VanillaIO instance: ", err)
-	// 	}
+	case "vanilla", "vanillaio", "terminal", "term", "graphics", "bitmap", "sixel", "kitty":
+		inout, err = vanillaio.New(conf.FgColor, conf.BgColor, conf.Palette, conf.TermColors, getTermGraphics(conf.IOType))
+		if err != nil {
+			log.Fatal("Fatal: Failed to Create new VanillaIO instance: ", err)
+		}
+	case "web", "webio", "browser":
+		var web *webio.WebIO
+		if web, err = webio.New(conf.WebAddress, conf.FgColor, conf.BgColor, conf.Palette); err != nil {
+			log.Fatal("Fatal: Failed to Create new WebIO instance: ", err)
+		}
+		fmt.Println("\t\tOpen", web.URL(), "in a browser to play")
+		inout = web
+	case "sdl", "graphical", "gui":
+		inout, err = newSdlIO(conf, dh, dw)
+		if err != nil {
+			log.Fatal("Fatal: Failed to Create new SdlIO instance: ", err)
+		}
 
 	default:
 		log.Fatal("Fatal: Failed to Create new IO instance: Invalid ioType: ", conf.IOType)
 	}
-	io.ListenForControl(terminationCh)
-	return io, byte(dh), byte(dw), nil
+	if hotkeyIO, ok := inout.(io.HotkeyIO); ok {
+		hotkeyIO.SetHotkeyChannel(hotkeyCh)
+	}
+	inout.ListenForControl(terminationCh)
+	return inout, byte(dh), byte(dw), nil
+}
+
+// getScript ... Loads the keypad script given by the -script flag, or returns nil if it wasnt given
+func getScript() *headlessio.Script {
+	if *scriptFlag == "" {
+		return nil
+	}
+	script, err := headlessio.LoadScript(*scriptFlag)
+	if err != nil {
+		log.Fatal("Fatal: Failed to load script: ", err)
+	}
+	return script
+}
+
+// getRandom ... Creates the chip's random source from the config, seeded by the -seed flag, the config's RandomSeed, or a random seed (in that order of preference)
+// returns the seed used so that it can be reported
+func getRandom(conf config.Config) (chip8.Random, uint64) {
+	seed := *seedFlag
+	if seed == 0 {
+		seed = conf.RandomSeed
+	}
+	if seed == 0 {
+		seed = rand.Uint64()
+	}
+	random, err := chip8.NewRandom(conf.Random, seed)
+	if err != nil {
+		log.Fatal("Fatal: Failed to create random source: ", err)
+	}
+	return random, seed
+}
+
+// getCyclesPerFrame ... Returns the chip's VIP timing budget for the configured Timing, or 0 to run a fixed number of instructions every frame
+func getCyclesPerFrame(conf config.Config) int {
+	switch conf.Timing {
+	case "instructions", "":
+		return 0
+	case "vip", "cosmac":
+		if conf.CyclesPerFrame > 0 {
+			return conf.CyclesPerFrame
+		}
+		return chip8.VIPCyclesPerFrame
+	}
+	log.Fatal("Fatal: Invalid Timing: ", conf.Timing)
+	return 0
+}
+
+// getMovie ... Loads the movie given by the -play or -verify flag, or returns nil if neither was given
+func getMovie() *movie.Movie {
+	path := *playFlag
+	if *verifyFlag != "" {
+		path = *verifyFlag
+	}
+	if path == "" {
+		return nil
+	}
+	mov, err := movie.Load(path)
+	if err != nil {
+		log.Fatal("Fatal: Failed to load movie: ", err)
+	}
+	return mov
+}
+
+// trapError ... The chip8.TrapFunc used when OnError is "trap". Logs the failed instruction along with the chip's registers and then skips over it
+func trapError(chip *chip8.Chip8, err *chip8.ExecError) error {
+	log.Printf("Trap: %v (V=% x I=%#04x SP=%d)", err, chip.V, chip.I, chip.SP)
+	chip.PC = err.PC + 2
+	return nil
+}
+
+// getScreenshotDir ... Returns the directory screenshots are saved to: the config's ScreenshotDir, or the screenshots directory in the config directory
+func getScreenshotDir(conf config.Config) string {
+	if conf.ScreenshotDir != "" {
+		return conf.ScreenshotDir
+	}
+	return filepath.Join(getConfigDir(), "screenshots")
+}
+
+// takeScreenshot ... Saves the chip's display to a PNG in the screenshot directory, drawn with the configured colours and scale
+// returns the path of the screenshot
+func takeScreenshot(conf config.Config, chip *chip8.Chip8) (string, error) {
+	palette := capture.NewPalette(conf.FgColor, conf.BgColor, conf.Palette)
+	return capture.Screenshot(getScreenshotDir(conf), getProgramName(conf), chip.Framebuffer(), palette, conf.ScreenshotScale)
+}
+
+// getCaptureFormat ... Returns the format to record gameplay in: the -capture-format flag, the extension of the -capture file, or the config's CaptureFormat
+func getCaptureFormat(conf config.Config) string {
+	if *captureFormatFlag != "" {
+		return *captureFormatFlag
+	}
+	if ext := strings.TrimPrefix(filepath.Ext(*captureFlag), "."); capture.CheckFormat(ext) == nil {
+		return ext
+	}
+	if conf.CaptureFormat != "" {
+		return conf.CaptureFormat
+	}
+	return capture.FormatGIF
+}
+
+// getCaptureFrames ... Returns the first frame to record and the frame to stop at, from the -capture-frames flag
+func getCaptureFrames() (uint64, uint64) {
+	var start, stop uint64
+	if _, err := fmt.Sscanf(*captureFramesFlag, "%d:%d", &start, &stop); err != nil || stop <= start {
+		log.Fatal("Fatal: Invalid -capture-frames: ", *captureFramesFlag, ". must be START:STOP, with STOP after START")
+	}
+	return start, stop
+}
+
+// getRecorder ... Creates the recorder gameplay is recorded with, drawn with the configured colours
+func getRecorder(conf config.Config) *capture.Recorder {
+	palette := capture.NewPalette(conf.FgColor, conf.BgColor, conf.Palette)
+	recorder, err := capture.NewRecorder(getScreenshotDir(conf), getProgramName(conf), getCaptureFormat(conf), palette, conf.CaptureScale, conf.CaptureDecimation)
+	if err != nil {
+		log.Fatal("Fatal: Failed to create recorder: ", err)
+	}
+	return recorder
+}
+
+// handleHotkey ... Saves and loads save states, takes screenshots, and starts and stops recordings. Called by the runner between frames
+func handleHotkey(hotkey io.Hotkey, conf config.Config, chip *chip8.Chip8, slots *savestate.Slots, recorder *capture.Recorder) {
+	var err error
+	switch hotkey {
+	case io.HotkeyScreenshot:
+		if _, err := takeScreenshot(conf, chip); err != nil {
+			log.Println("Screenshot: ", err)
+		}
+	case io.HotkeyRecord:
+		if _, _, err := recorder.Toggle(); err != nil {
+			log.Println("Recording: ", err)
+		}
+	case io.HotkeySaveState:
+		err = slots.Save(chip)
+	case io.HotkeyLoadState:
+		err = slots.Load(chip)
+	case io.HotkeyNextSlot:
+		slots.Next()
+	case io.HotkeyPrevSlot:
+		slots.Prev()
+	}
+	if err != nil {
+		log.Println("Save state: ", err)
+	}
 }
 
 //#endregion
 
 func main() {
+	flag.Parse()
+	var code int
+	runMain(func() { code = emulate() })
+	os.Exit(code)
+}
+
+// exitCode ... Returns the status the program exits with once inout has been terminated. 1 for TcellIO, as it always has, and 0 for every other backend
+func exitCode(inout io.IO) int {
+	if _, ok := inout.(*tcellio.TcellIO); ok {
+		return 1
+	}
+	return 0
+}
+
+// emulate ... Sets up the chip and its frontend from the config and flags, and runs it until it exits or the user terminates it. Returns the status the program exits with
+func emulate() (code int) {
 	var inout io.IO
+	// gameplay recorded to standard output cant share it with anything else, so everything that would be printed goes to standard error instead
+	if *captureFlag == capture.Stdout {
+		if *captureFramesFlag == "" {
+			log.Fatal("Fatal: Recording to standard output needs -capture-frames")
+		}
+		os.Stdout = os.Stderr
+	}
+	if *captureFramesFlag != "" && (*captureFlag == "" || *screenshotFlag != 0) {
+		log.Fatal("Fatal: -capture-frames needs -capture, and cant be combined with -screenshot")
+	}
 
 	fmt.Println("Initializing GoChip-8...")
 	fmt.Println("\tLoading Config...")
 	confPath := getConfigPath()
 	fmt.Println("\t\tFound configuration at:", confPath)
 	conf := loadConfig(confPath)
-	fmt.Println("\t\tSetting instruction Delay...")
-	delay := time.Second / time.Duration(conf.InstructionsPerSecond)
+	fmt.Println("\t\tResolving Quirks...")
+	quirks, err := conf.Quirks.Resolve()
+	if err != nil {
+		log.Fatal("\t\tFatal: Failed to resolve quirks: ", err)
+	}
+	// movies are played back with the quirks and random source they were recorded with, whatever the config says
+	mov := getMovie()
+	if mov != nil {
+		quirks = mov.Quirks
+		conf.Random = mov.Random
+		if *seedFlag, err = mov.RandomSeed(); err != nil {
+			log.Fatal("\t\tFatal: Failed to read movie: ", err)
+		}
+	}
 	fmt.Println("\t\tConfig Loaded.")
 
 	fmt.Println("\tInitializing I/O...")
-	inout, dh, dw, err := createIo(conf)
-	if err != nil {
+	var dh, dw byte
+	headless := *headlessFlag || conf.IOType == "headless" || *verifyFlag != "" || *screenshotFlag != 0 || *captureFramesFlag != ""
+	script := getScript()
+	if headless {
+		rows, cols := getDisplaySize(quirks)
+		inout, dh, dw = headlessio.New(script, nil), byte(rows), byte(cols)
+	} else if inout, dh, dw, err = createIo(conf, quirks); err != nil {
 		log.Fatal("\t\tFatal: Failed to create IO instance")
 	}
 	fmt.Println("\t\tIO Initialized.")
 
 	fmt.Println("\tInitializing Chip Instance...")
 	font := getDefaultFont(conf)
+	bigFont := getBigFont()
 	program := getProgram(conf)
-	chip := chip8.New(conf, inout, program, font, dh, dw)
+	random, seed := getRandom(conf)
+	fmt.Println("\t\tRandom seed:", seed)
+
+	// frames are rendered on their own goroutine, except without a display where nothing is rendered at all
+	var presenter *present.Presenter
+	var display io.Display = inout
+	if !headless {
+		presenter = present.New(inout, conf.FrameSkip, conf.ShowStats)
+		display = presenter
+	}
 
+	// the keypad is routed through a movie recorder or player when recording or playing back
+	var keypad io.Keypad = inout
+	ipf := max(int(conf.InstructionsPerSecond)/runner.FrameRate, 1)
+	cyclesPerFrame := getCyclesPerFrame(conf)
+	if mov != nil {
+		if err := mov.CheckROM(program); err != nil {
+			log.Fatal("\t\tFatal: ", err)
+		}
+		keypad = movie.NewPlayer(mov, keypad)
+		ipf = mov.InstructionsPerFrame
+		cyclesPerFrame = mov.CyclesPerFrame
+	} else if *recordFlag != "" {
+		mov = movie.New(program, quirks, conf.Random, seed, ipf, cyclesPerFrame)
+		keypad = movie.NewRecorder(mov, keypad)
+	}
+
+	// backends without sound leave the chip silent
+	audio, _ := inout.(io.Audio)
+	chip, err := chip8.New(quirks, display, keypad, audio, random, program, font, bigFont, dh, dw)
+	if err != nil {
+		log.Fatal("\t\tFatal: Failed to create chip: ", err)
+	}
+	chip.ErrorPolicy, err = chip8.ParseErrorPolicy(conf.OnError)
+	if err != nil {
+		log.Fatal("\t\tFatal: Failed to set error policy: ", err)
+	}
+	chip.TrapFunc = trapError
+	chip.CyclesPerFrame = cyclesPerFrame
+
+	if *verifyFlag != "" {
+		if err := mov.Verify(chip); err != nil {
+			log.Fatal("Fatal: ", err)
+		}
+		fmt.Println("Movie verified:", mov.Frames, "frames played back to the recorded display")
+		return 0
+	}
+	if *screenshotFlag != 0 {
+		for chip.Frame < *screenshotFlag && !chip.Exited() {
+			if err := chip.RunFrame(ipf); err != nil {
+				log.Println("Halted: ", err)
+				break
+			}
+		}
+		path, err := takeScreenshot(conf, chip)
+		if err != nil {
+			log.Fatal("Fatal: ", err)
+		}
+		fmt.Println("Saved screenshot of frame", chip.Frame, "to", path)
+		return 0
+	}
+
+	recorder := getRecorder(conf)
+	if *captureFlag != "" {
+		if _, err := recorder.Start(*captureFlag); err != nil {
+			log.Fatal("Fatal: Failed to start recording: ", err)
+		}
+	}
+	if *captureFramesFlag != "" {
+		start, stop := getCaptureFrames()
+		for chip.Frame < stop {
+			if chip.Frame >= start {
+				if err := recorder.Frame(chip.Framebuffer()); err != nil {
+					log.Fatal("Fatal: ", err)
+				}
+			}
+			if chip.Exited() {
+				break
+			}
+			if err := chip.RunFrame(ipf); err != nil {
+				log.Println("Halted: ", err)
+				break
+			}
+		}
+		path, err := recorder.Stop()
+		if err != nil {
+			log.Fatal("Fatal: ", err)
+		}
+		fmt.Println("Recorded frames", start, "to", chip.Frame, "to", path)
+		return 0
+	}
+
+	if presenter != nil {
+		presenter.Attach(chip)
+		presenter.Start()
+	}
 	defer func() {
+		if presenter != nil {
+			presenter.Stop()
+		}
 		fmt.Println("C\nU\nNext\nTime!")
-		chip.Terminate()
 		inout.Terminate()
+		code = exitCode(inout)
 	}()
 
-	active := true
+	slots, err := savestate.New(filepath.Join(getConfigDir(), "saves"), getProgramName(conf))
+	if err != nil {
+		log.Fatal("\t\tFatal: Failed to create save state directory: ", err)
+	}
+
 	// Main Loop
-	for {
-		select {
-		case <-terminationCh:
-			inout.Terminate()
-			return
-		default:
-			if active {
-				time.Sleep(delay)
-				chip.MainLoop()
+	run := runner.New(chip, uint32(ipf*runner.FrameRate))
+	// without a display there are no hotkeys to handle.
+	// rewinding and loading states would break the frame numbering of a movie, so both are only available outside of one
+	if !headless {
+		if mov == nil {
+			run.EnableRewind(conf.RewindDepth)
+		}
+		run.HandleHotkeys(hotkeyCh, func(hotkey io.Hotkey) {
+			if mov != nil && hotkey == io.HotkeyLoadState {
+				log.Println("Save state: states cant be loaded during a movie")
+				return
 			}
+			handleHotkey(hotkey, conf, chip, slots, recorder)
+		})
+	}
+	if *playFlag != "" {
+		run.StopAtFrame(mov.Frames)
+	}
+	if script != nil && script.End != 0 {
+		run.StopAtFrame(script.End)
+	}
+	if *framesFlag != 0 {
+		run.StopAtFrame(*framesFlag)
+	}
+	if headless {
+		run.DisablePacing()
+	}
+	run.AfterFrame(func() {
+		if err := recorder.Frame(chip.Framebuffer()); err != nil {
+			log.Println("Recording: ", err)
+		}
+	})
+	if err := run.Run(terminationCh); err != nil {
+		log.Println("Halted: ", err)
+	}
+	if _, err := recorder.Stop(); err != nil {
+		log.Println("Recording: ", err)
+	}
+	if headless {
+		fmt.Println("Ran", chip.Frame, "frames, ending on display", movie.HashDisplay(chip))
+	}
+
+	if *recordFlag != "" {
+		mov.Finish(chip)
+		if err := mov.Save(*recordFlag); err != nil {
+			log.Println("Movie: ", err)
 		}
 	}
+	return 0
 }
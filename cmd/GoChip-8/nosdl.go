@@ -0,0 +1,20 @@
+//go:build !sdl
+
+package main
+
+import (
+	"errors"
+
+	"github.com/TH3-F001/GoChip-8/chip8/internal/config"
+	"github.com/TH3-F001/GoChip-8/chip8/internal/io"
+)
+
+// newSdlIO ... Stands in for the SDL backend in builds without it
+func newSdlIO(conf config.Config, dh, dw int) (io.IO, error) {
+	return nil, errors.New("GoChip-8 was built without SDL support (rebuild with -tags sdl)")
+}
+
+// runMain ... Runs the emulator. Without SDL there is nothing that needs the main OS thread
+func runMain(emulate func()) {
+	emulate()
+}
@@ -0,0 +1,24 @@
+//go:build sdl
+
+package main
+
+import (
+	"github.com/TH3-F001/GoChip-8/chip8/internal/config"
+	"github.com/TH3-F001/GoChip-8/chip8/internal/io"
+	"github.com/TH3-F001/GoChip-8/chip8/internal/io/sdlio"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// newSdlIO ... Creates the SDL backend. Needs libsdl2 to build, so it is only built in with -tags sdl
+func newSdlIO(conf config.Config, dh, dw int) (io.IO, error) {
+	inout, err := sdlio.New(dw, dh, conf.FgColor, conf.BgColor, conf.Palette, conf.Scale)
+	if err != nil {
+		return nil, err
+	}
+	return inout, nil
+}
+
+// runMain ... Runs the emulator on a goroutine of its own, keeping the main OS thread free for the SDL calls sdlio hands it
+func runMain(emulate func()) {
+	sdl.Main(emulate)
+}
@@ -0,0 +1,37 @@
+package emulator
+
+import (
+	"github.com/TH3-F001/GoChip-8/chip8/internal/framebuffer"
+)
+
+// Frame ... A copy of the display. Pixels holds one byte per pixel, row by row, with bit 0 set if the pixel is lit on the first bitplane
+// and bit 1 set if it is lit on the second (XO-CHIP) bitplane
+type Frame struct {
+	Width  int
+	Height int
+	Pixels []byte
+}
+
+// At ... Returns the bitplanes lit at the given column and row. 0 if out of bounds
+func (f Frame) At(col, row int) byte {
+	if col < 0 || col >= f.Width || row < 0 || row >= f.Height {
+		return 0
+	}
+	return f.Pixels[row*f.Width+col]
+}
+
+// Lit ... Returns true if the pixel at the given column and row is lit on any bitplane
+func (f Frame) Lit(col, row int) bool {
+	return f.At(col, row) != 0
+}
+
+// newFrame ... Copies a chip's framebuffer into a Frame
+func newFrame(fb *framebuffer.Framebuffer) Frame {
+	f := Frame{Width: fb.Width(), Height: fb.Height(), Pixels: make([]byte, fb.Width()*fb.Height())}
+	for row := 0; row < f.Height; row++ {
+		for col := 0; col < f.Width; col++ {
+			f.Pixels[row*f.Width+col] = fb.Pixel(row, col)
+		}
+	}
+	return f
+}
@@ -0,0 +1,350 @@
+// Package emulator is the public, embeddable interface to the GoChip-8 interpreter.
+// A Machine runs a single CHIP-8, SUPER-CHIP or XO-CHIP program entirely in memory: the caller presses and releases keys, and reads the display
+// back as a Frame. Machines share no state, so any number of them can run in one process
+package emulator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/TH3-F001/GoChip-8/chip8/internal/chip8"
+	"github.com/TH3-F001/GoChip-8/chip8/internal/config"
+	"github.com/TH3-F001/GoChip-8/chip8/internal/fonts"
+	"github.com/TH3-F001/GoChip-8/chip8/internal/io"
+)
+
+// FrameRate ... The number of frames Run emulates per second of real time
+const FrameRate = 60
+
+// Quirks ... The platform behaviours and instruction set extensions of the emulated interpreter
+type Quirks = config.Quirks
+
+// ErrorPolicy ... Decides what happens when an instruction fails. see Halt and Skip
+type ErrorPolicy = chip8.ErrorPolicy
+
+const (
+	// Halt ... Stops the machine and returns the error
+	Halt = chip8.Halt
+	// Skip ... Skips the failed instruction and carries on
+	Skip = chip8.Skip
+)
+
+// ErrNoROM ... Returned when a Machine is stepped or run before a ROM has been loaded
+var ErrNoROM = errors.New("no ROM loaded")
+
+// Preset ... Returns the quirks of a named interpreter preset: cosmac, chip48, schip10, schip11, schipmodern or xochip
+func Preset(name string) (Quirks, bool) {
+	quirks, ok := config.Presets[name]
+	return quirks, ok
+}
+
+// #region Options
+
+// Option ... Configures a Machine. Passed to New
+type Option func(*options) error
+
+// options ... Everything a Machine is configured with
+type options struct {
+	quirks               Quirks
+	instructionsPerFrame int
+	cyclesPerFrame       int
+	random               string
+	seed                 uint64
+	font                 string
+	errorPolicy          ErrorPolicy
+}
+
+// WithPreset ... Uses the quirks of a named interpreter preset. see Preset
+func WithPreset(name string) Option {
+	return func(o *options) error {
+		quirks, ok := Preset(name)
+		if !ok {
+			return fmt.Errorf("error in emulator/WithPreset(): unknown preset %q", name)
+		}
+		o.quirks = quirks
+		return nil
+	}
+}
+
+// WithQuirks ... Uses the given quirks
+func WithQuirks(quirks Quirks) Option {
+	return func(o *options) error {
+		o.quirks = quirks
+		return nil
+	}
+}
+
+// WithInstructionsPerSecond ... Sets how many instructions are executed every second. Defaults to 700
+func WithInstructionsPerSecond(ips int) Option {
+	return func(o *options) error {
+		if ips < FrameRate {
+			return fmt.Errorf("error in emulator/WithInstructionsPerSecond(): need at least %d instructions per second, got %d", FrameRate, ips)
+		}
+		o.instructionsPerFrame = ips / FrameRate
+		return nil
+	}
+}
+
+// WithVIPTiming ... Paces instructions by what they cost on a COSMAC VIP rather than by a fixed instruction count.
+// cyclesPerFrame is the machine cycle budget of every frame. 0 uses the VIP's own
+func WithVIPTiming(cyclesPerFrame int) Option {
+	return func(o *options) error {
+		if cyclesPerFrame <= 0 {
+			cyclesPerFrame = chip8.VIPCyclesPerFrame
+		}
+		o.cyclesPerFrame = cyclesPerFrame
+		return nil
+	}
+}
+
+// WithRandom ... Sets the random number generator used by the RND instruction ("pcg" or "page") and its seed, making runs repeatable.
+// An empty generator name is pcg. By default a pcg generator with a random seed is used
+func WithRandom(generator string, seed uint64) Option {
+	return func(o *options) error {
+		if generator == "" {
+			// LoadROM only seeds a named generator, so the default has to be named for the seed to be kept
+			generator = "pcg"
+		}
+		if _, err := chip8.NewRandom(generator, seed); err != nil {
+			return fmt.Errorf("error in emulator/WithRandom(): %w", err)
+		}
+		o.random, o.seed = generator, seed
+		return nil
+	}
+}
+
+// WithFont ... Sets the hex digit font: chip48, cosmac, dream or eti. Defaults to chip48
+func WithFont(name string) Option {
+	return func(o *options) error {
+		o.font = name
+		return nil
+	}
+}
+
+// WithErrorPolicy ... Sets what happens when an instruction fails. Defaults to Halt
+func WithErrorPolicy(policy ErrorPolicy) Option {
+	return func(o *options) error {
+		if policy != Halt && policy != Skip {
+			return fmt.Errorf("error in emulator/WithErrorPolicy(): unsupported error policy %v", policy)
+		}
+		o.errorPolicy = policy
+		return nil
+	}
+}
+
+//#endregion
+
+// Machine ... A single emulated CHIP-8. Should be instantiated using emulator.New(). All methods are safe to call from any goroutine
+// mu guards chip and keypad, which are replaced whenever a ROM is loaded. keypad is the chip's only IO, pressed by the Machine's caller
+// and driven frame by frame by the chip, so Fx0A behaves the same however fast frames are run. the chip has no display since frames are read straight from it by Frame
+// paused is checked by Run before every frame
+type Machine struct {
+	opts   options
+	mu     sync.Mutex
+	chip   *chip8.Chip8
+	keypad *io.KeyState
+	paused atomic.Bool
+}
+
+// New ... Creates a Machine configured by opts. A ROM must be loaded with LoadROM before it can run
+func New(opts ...Option) (*Machine, error) {
+	m := Machine{
+		opts: options{
+			quirks:               config.Presets[config.DefaultPreset],
+			instructionsPerFrame: 700 / FrameRate,
+		},
+	}
+	for _, opt := range opts {
+		if err := opt(&m.opts); err != nil {
+			return nil, err
+		}
+	}
+	return &m, nil
+}
+
+// LoadROM ... Resets the machine and loads rom into it, ready to run from the start
+func (m *Machine) LoadROM(rom []byte) error {
+	small, err := fonts.Small(m.opts.font)
+	if err != nil {
+		return fmt.Errorf("error in emulator/Machine.LoadROM(): %w", err)
+	}
+	big, err := fonts.Big()
+	if err != nil {
+		return fmt.Errorf("error in emulator/Machine.LoadROM(): %w", err)
+	}
+	var random chip8.Random
+	if m.opts.random != "" {
+		if random, err = chip8.NewRandom(m.opts.random, m.opts.seed); err != nil {
+			return fmt.Errorf("error in emulator/Machine.LoadROM(): %w", err)
+		}
+	}
+
+	rows, cols := 32, 64
+	if m.opts.quirks.SuperChip {
+		rows, cols = 64, 128
+	}
+	keypad := io.NewKeyState(0)
+	chip, err := chip8.New(m.opts.quirks, nil, keypad, nil, random, rom, small, big, byte(rows), byte(cols))
+	if err != nil {
+		return fmt.Errorf("error in emulator/Machine.LoadROM(): %w", err)
+	}
+	chip.ErrorPolicy = m.opts.errorPolicy
+	chip.CyclesPerFrame = m.opts.cyclesPerFrame
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.chip, m.keypad = chip, keypad
+	return nil
+}
+
+// LoadROMFile ... Reads the ROM at path and loads it with LoadROM
+func (m *Machine) LoadROMFile(path string) error {
+	rom, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error in emulator/Machine.LoadROMFile(): %w", err)
+	}
+	return m.LoadROM(rom)
+}
+
+// Step ... Executes a single instruction without touching the timers. Failed instructions are always returned, the ErrorPolicy only applies to RunFrame and Run
+func (m *Machine) Step() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.chip == nil {
+		return ErrNoROM
+	}
+	return m.chip.Step()
+}
+
+// RunFrame ... Emulates a single 60Hz frame as fast as possible
+func (m *Machine) RunFrame() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.chip == nil {
+		return ErrNoROM
+	}
+	return m.chip.RunFrame(m.opts.instructionsPerFrame)
+}
+
+// Run ... Runs a frame every 60th of a second until ctx is cancelled, the program exits, or an instruction fails. Blocks until then.
+// No frames are run while the machine is paused. returns ctx.Err() if cancelled, nil if the program exited, or the error the machine halted on
+func (m *Machine) Run(ctx context.Context) error {
+	ticker := time.NewTicker(time.Second / FrameRate)
+	defer ticker.Stop()
+	for !m.Exited() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if m.paused.Load() {
+				continue
+			}
+			if err := m.RunFrame(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Pause ... Stops Run from emulating any more frames until Resume is called
+func (m *Machine) Pause() {
+	m.paused.Store(true)
+}
+
+// Resume ... Lets Run carry on after Pause
+func (m *Machine) Resume() {
+	m.paused.Store(false)
+}
+
+// Paused ... Returns true while the machine is paused
+func (m *Machine) Paused() bool {
+	return m.paused.Load()
+}
+
+// Exited ... Returns true once the program has executed 00FD (EXIT)
+func (m *Machine) Exited() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.chip != nil && m.chip.Exited()
+}
+
+// FrameCount ... Returns the number of frames emulated since the ROM was loaded
+func (m *Machine) FrameCount() uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.chip == nil {
+		return 0
+	}
+	return m.chip.Frame
+}
+
+// Frame ... Returns a copy of the display. The Frame is empty if no ROM has been loaded
+func (m *Machine) Frame() Frame {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.chip == nil {
+		return Frame{}
+	}
+	return newFrame(m.chip.Framebuffer())
+}
+
+// SoundOn ... Returns true while the sound timer is running, which is when the machine should beep
+func (m *Machine) SoundOn() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.chip != nil && m.chip.ST > 0
+}
+
+// PressKey ... Holds down a hex key (0x0-0xF)
+func (m *Machine) PressKey(key byte) error {
+	return m.setKey(key, true)
+}
+
+// ReleaseKey ... Releases a hex key (0x0-0xF)
+func (m *Machine) ReleaseKey(key byte) error {
+	return m.setKey(key, false)
+}
+
+func (m *Machine) setKey(key byte, down bool) error {
+	if key > 0xF {
+		return fmt.Errorf("error in emulator/Machine.setKey(): invalid key %#x", key)
+	}
+	m.mu.Lock()
+	keypad := m.keypad
+	m.mu.Unlock()
+	if keypad == nil {
+		return ErrNoROM
+	}
+	if down {
+		keypad.Press(key)
+	} else {
+		keypad.Release(key)
+	}
+	return nil
+}
+
+// Snapshot ... Returns the machine's entire state, which can be passed to Restore to return to this point
+func (m *Machine) Snapshot() ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.chip == nil {
+		return nil, ErrNoROM
+	}
+	return m.chip.Snapshot()
+}
+
+// Restore ... Returns the machine to a state returned by Snapshot
+func (m *Machine) Restore(snapshot []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.chip == nil {
+		return ErrNoROM
+	}
+	return m.chip.Restore(snapshot)
+}
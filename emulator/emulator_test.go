@@ -0,0 +1,236 @@
+package emulator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// scatter ... Draws its own first bytes as a sprite at random positions forever: A200 C03F C11F D015 1202
+var scatter = []byte{0xA2, 0x00, 0xC0, 0x3F, 0xC1, 0x1F, 0xD0, 0x15, 0x12, 0x02}
+
+// runScatter ... Runs scatter for 30 frames on a Machine configured by opts, and returns its display
+func runScatter(t *testing.T, opts ...Option) []byte {
+	t.Helper()
+	m, err := New(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.LoadROM(scatter); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 30; i++ {
+		if err := m.RunFrame(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return m.Frame().Pixels
+}
+
+func TestWithRandomSeedsRuns(t *testing.T) {
+	tests := []struct {
+		name      string
+		generator string
+	}{
+		{name: "default generator", generator: ""},
+		{name: "pcg", generator: "pcg"},
+		{name: "page", generator: "page"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first := runScatter(t, WithRandom(tt.generator, 42))
+			if again := runScatter(t, WithRandom(tt.generator, 42)); !bytes.Equal(first, again) {
+				t.Error("two runs with the same seed drew different displays")
+			}
+			if other := runScatter(t, WithRandom(tt.generator, 43)); bytes.Equal(first, other) {
+				t.Error("runs with different seeds drew the same display")
+			}
+		})
+	}
+}
+
+// newLoaded ... Creates a Machine configured by opts with rom loaded
+func newLoaded(t *testing.T, rom []byte, opts ...Option) *Machine {
+	t.Helper()
+	m, err := New(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.LoadROM(rom); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestMachineNeedsROM(t *testing.T) {
+	m, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Step(); !errors.Is(err, ErrNoROM) {
+		t.Errorf("Step() error = %v, want ErrNoROM", err)
+	}
+	if err := m.RunFrame(); !errors.Is(err, ErrNoROM) {
+		t.Errorf("RunFrame() error = %v, want ErrNoROM", err)
+	}
+	if err := m.PressKey(0x1); !errors.Is(err, ErrNoROM) {
+		t.Errorf("PressKey() error = %v, want ErrNoROM", err)
+	}
+	if frame := m.Frame(); frame.Width != 0 || m.FrameCount() != 0 || m.Exited() {
+		t.Errorf("Frame(), FrameCount(), Exited() = %dx%d, %d, %v, want an empty machine", frame.Width, frame.Height, m.FrameCount(), m.Exited())
+	}
+	if err := m.LoadROMFile(filepath.Join(t.TempDir(), "missing.ch8")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("LoadROMFile() of a missing file error = %v, want os.ErrNotExist", err)
+	}
+}
+
+func TestLoadROMFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scatter.ch8")
+	if err := os.WriteFile(path, scatter, 0644); err != nil {
+		t.Fatal(err)
+	}
+	m, err := New(WithRandom("pcg", 42))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.LoadROMFile(path); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 30; i++ {
+		if err := m.RunFrame(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !bytes.Equal(m.Frame().Pixels, runScatter(t, WithRandom("pcg", 42))) {
+		t.Error("the ROM loaded from a file drew a different display to the same ROM loaded from memory")
+	}
+}
+
+func TestStepAndRunFrame(t *testing.T) {
+	// 6001 is fine, FFFF isnt an instruction
+	m := newLoaded(t, []byte{0x60, 0x01, 0xFF, 0xFF}, WithInstructionsPerSecond(600))
+	if err := m.Step(); err != nil {
+		t.Fatal(err)
+	}
+	if m.FrameCount() != 0 {
+		t.Errorf("FrameCount() after Step() = %d, want 0", m.FrameCount())
+	}
+	if err := m.Step(); err == nil {
+		t.Error("Step() of FFFF error = nil, want an error")
+	}
+
+	// the loop 1200 runs forever, counting a frame every RunFrame. 00FD then ends it
+	m = newLoaded(t, []byte{0x12, 0x00})
+	for i := 0; i < 3; i++ {
+		if err := m.RunFrame(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if m.FrameCount() != 3 || m.Exited() {
+		t.Errorf("FrameCount(), Exited() = %d, %v, want 3, false", m.FrameCount(), m.Exited())
+	}
+	if frame := m.Frame(); frame.Width != 64 || frame.Height != 32 || len(frame.Pixels) != 64*32 {
+		t.Errorf("Frame() is %dx%d with %d pixels, want 64x32", frame.Width, frame.Height, len(frame.Pixels))
+	}
+	m = newLoaded(t, []byte{0x00, 0xFD}, WithPreset("schip11"))
+	if err := m.RunFrame(); err != nil {
+		t.Fatal(err)
+	}
+	if !m.Exited() {
+		t.Error("Exited() = false after 00FD")
+	}
+	if frame := m.Frame(); frame.Width != 128 || frame.Height != 64 {
+		t.Errorf("SUPER-CHIP Frame() is %dx%d, want 128x64", frame.Width, frame.Height)
+	}
+}
+
+func TestKeyInput(t *testing.T) {
+	// F00A waits for a key, then 3005 skips the jump back to the wait if it was 5, exiting with 00FD
+	m := newLoaded(t, []byte{0xF0, 0x0A, 0x30, 0x05, 0x12, 0x00, 0x00, 0xFD}, WithPreset("schip11"))
+	runFrames := func(frames int) {
+		t.Helper()
+		for i := 0; i < frames; i++ {
+			if err := m.RunFrame(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := m.PressKey(0x10); err == nil {
+		t.Error("PressKey(0x10) error = nil, want an error")
+	}
+
+	// a key pressed before the wait began doesnt end it
+	m.PressKey(0x5)
+	m.ReleaseKey(0x5)
+	runFrames(3)
+	if m.Exited() {
+		t.Fatal("a key pressed before Fx0A ended its wait")
+	}
+	m.PressKey(0x7)
+	runFrames(2)
+	m.ReleaseKey(0x7)
+	if m.Exited() {
+		t.Fatal("exited on key 7")
+	}
+	// holding 7 down didnt press it again, and 5 is a fresh press
+	m.PressKey(0x5)
+	runFrames(1)
+	if !m.Exited() {
+		t.Error("Exited() = false after pressing 5 during the wait")
+	}
+}
+
+func TestPauseAndResume(t *testing.T) {
+	// 6003 F015 sets the delay timer to 3, then F007 3000 1204 loops until it runs out and 00FD exits
+	m := newLoaded(t, []byte{0x60, 0x03, 0xF0, 0x15, 0xF0, 0x07, 0x30, 0x00, 0x12, 0x04, 0x00, 0xFD}, WithPreset("schip11"))
+	m.Pause()
+	if !m.Paused() {
+		t.Error("Paused() = false after Pause()")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := m.Run(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Run() while paused error = %v, want context.DeadlineExceeded", err)
+	}
+	if m.FrameCount() != 0 {
+		t.Errorf("FrameCount() after running paused = %d, want 0", m.FrameCount())
+	}
+
+	m.Resume()
+	if m.Paused() {
+		t.Error("Paused() = true after Resume()")
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := m.Run(ctx); err != nil {
+		t.Fatalf("Run() error = %v, want nil once the program exits", err)
+	}
+	if !m.Exited() || m.FrameCount() < 3 {
+		t.Errorf("Exited(), FrameCount() = %v, %d, want true after at least 3 frames", m.Exited(), m.FrameCount())
+	}
+}
+
+func TestSnapshotAndRestore(t *testing.T) {
+	m := newLoaded(t, scatter, WithRandom("pcg", 1))
+	if err := m.RunFrame(); err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := m.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := m.Frame().Pixels
+	for i := 0; i < 5; i++ {
+		m.RunFrame()
+	}
+	if err := m.Restore(snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if m.FrameCount() != 1 || !bytes.Equal(m.Frame().Pixels, want) {
+		t.Errorf("restored to frame %d with a different display, want frame 1", m.FrameCount())
+	}
+}
@@ -4,9 +4,9 @@ go 1.23.1
 
 require (
 	github.com/BurntSushi/toml v1.4.0
-	github.com/TH3-F001/gotoolshed v0.0.0-20240916001930-044c7d2483dd
 	github.com/gdamore/tcell/v2 v2.7.4
 	github.com/veandco/go-sdl2 v0.4.40
+	golang.org/x/term v0.17.0
 )
 
 require (
@@ -15,6 +15,5 @@ require (
 	github.com/mattn/go-runewidth v0.0.15 // indirect
 	github.com/rivo/uniseg v0.4.3 // indirect
 	golang.org/x/sys v0.17.0 // indirect
-	golang.org/x/term v0.17.0 // indirect
 	golang.org/x/text v0.14.0 // indirect
 )
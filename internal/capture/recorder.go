@@ -0,0 +1,135 @@
+package capture
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/TH3-F001/GoChip-8/chip8/internal/framebuffer"
+)
+
+// Stdout ... The path that records to standard output instead of a file
+const Stdout = "-"
+
+// stdout ... Standard output as it was when the program started, so that recordings still go to it if os.Stdout is redirected to keep other output out of the recording
+var stdout = os.Stdout
+
+// Recorder ... Starts and stops recordings of gameplay, each to a file of its own. Should be instantiated using capture.NewRecorder()
+// dir and romPath name the files recordings are saved to when no path is given. format, palette, scale and decimation are passed on to every Video
+// video is the recording in progress, written to file at path. nil when not recording
+type Recorder struct {
+	dir        string
+	romPath    string
+	format     string
+	palette    Palette
+	scale      int
+	decimation int
+
+	video *Video
+	file  io.WriteCloser
+	path  string
+}
+
+// NewRecorder ... Creates a Recorder that saves recordings of romPath to dir in the given format
+func NewRecorder(dir, romPath, format string, palette Palette, scale, decimation int) (*Recorder, error) {
+	if err := CheckFormat(format); err != nil {
+		return nil, fmt.Errorf("error in capture/NewRecorder(): %w", err)
+	}
+	return &Recorder{
+		dir:        dir,
+		romPath:    romPath,
+		format:     format,
+		palette:    palette,
+		scale:      scale,
+		decimation: decimation,
+	}, nil
+}
+
+// Recording ... Returns true while a recording is in progress
+func (r *Recorder) Recording() bool {
+	return r.video != nil
+}
+
+// Start ... Starts recording to path, or to standard output if path is Stdout. An empty path saves to a new file in dir named after the ROM and the current time.
+// returns the path recorded to
+func (r *Recorder) Start(path string) (string, error) {
+	if r.video != nil {
+		return "", fmt.Errorf("error in capture/Recorder.Start(): already recording to %s", r.path)
+	}
+	var file io.WriteCloser
+	var err error
+	switch path {
+	case "":
+		var f *os.File
+		if f, err = create(r.dir, r.romPath, r.format); err == nil {
+			file, path = f, f.Name()
+		}
+	case Stdout:
+		file = nopCloser{stdout}
+	default:
+		file, err = os.Create(path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("error in capture/Recorder.Start(): %w", err)
+	}
+
+	video, err := NewVideo(file, r.format, r.palette, r.scale, r.decimation)
+	if err != nil {
+		file.Close()
+		return "", fmt.Errorf("error in capture/Recorder.Start(): %w", err)
+	}
+	r.video, r.file, r.path = video, file, path
+	return path, nil
+}
+
+// Toggle ... Stops the recording in progress, or starts a new one in dir if there isnt one.
+// returns the path recorded to, and whether a recording was started
+func (r *Recorder) Toggle() (string, bool, error) {
+	if r.video != nil {
+		path, err := r.Stop()
+		return path, false, err
+	}
+	path, err := r.Start("")
+	return path, true, err
+}
+
+// Frame ... Records fb if a recording is in progress. Must be called with every emulated frame. The recording is stopped if it fails
+func (r *Recorder) Frame(fb *framebuffer.Framebuffer) error {
+	if r.video == nil {
+		return nil
+	}
+	if err := r.video.Frame(fb); err != nil {
+		r.Stop()
+		return fmt.Errorf("error in capture/Recorder.Frame(): %w", err)
+	}
+	return nil
+}
+
+// Stop ... Finishes the recording in progress, if any, and closes its file.
+// returns the path recorded to
+func (r *Recorder) Stop() (string, error) {
+	if r.video == nil {
+		return "", nil
+	}
+	video, file, path := r.video, r.file, r.path
+	r.video, r.file, r.path = nil, nil, ""
+
+	err := video.Close()
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return path, fmt.Errorf("error in capture/Recorder.Stop(): %w", err)
+	}
+	return path, nil
+}
+
+// nopCloser ... Wraps standard output so that finishing a recording doesnt close it
+type nopCloser struct {
+	io.Writer
+}
+
+// Close ... Does nothing
+func (nopCloser) Close() error {
+	return nil
+}
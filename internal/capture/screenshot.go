@@ -0,0 +1,87 @@
+package capture
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/TH3-F001/GoChip-8/chip8/internal/framebuffer"
+	"github.com/TH3-F001/GoChip-8/chip8/internal/palette"
+)
+
+// timestampFormat ... The layout of the timestamp in the names of captured files. Includes milliseconds so that captures taken in quick succession dont collide
+const timestampFormat = "20060102-150405.000"
+
+// Palette ... The colour of each combination of lit bitplanes (none, first, second, both)
+type Palette [4]color.RGBA
+
+// NewPalette ... Creates a Palette from hex colours the same way every backend does. see palette.Resolve
+func NewPalette(fgColor, bgColor uint32, custom []uint32) Palette {
+	var p Palette
+	for i, hex := range palette.Resolve(fgColor, bgColor, custom) {
+		p[i] = color.RGBA{R: byte(hex >> 16), G: byte(hex >> 8), B: byte(hex), A: 0xFF}
+	}
+	return p
+}
+
+// colorPalette ... Returns the palette as an image/color palette, indexed by the bitplanes lit
+func (p Palette) colorPalette() color.Palette {
+	colors := make(color.Palette, len(p))
+	for i := range p {
+		colors[i] = p[i]
+	}
+	return colors
+}
+
+// Image ... Draws fb as a paletted image, with every pixel repeated scale times across and down
+func Image(fb *framebuffer.Framebuffer, palette Palette, scale int) *image.Paletted {
+	return paletted(fb, image.Rect(0, 0, fb.Width(), fb.Height()), palette, max(scale, 1))
+}
+
+// paletted ... Draws the pixels of fb inside bounds as a paletted image, with every pixel repeated scale times across and down.
+// The image's bounds are bounds scaled, so that it can be drawn over an image of the whole frame
+func paletted(fb *framebuffer.Framebuffer, bounds image.Rectangle, palette Palette, scale int) *image.Paletted {
+	img := image.NewPaletted(image.Rect(bounds.Min.X*scale, bounds.Min.Y*scale, bounds.Max.X*scale, bounds.Max.Y*scale), palette.colorPalette())
+	for y := img.Rect.Min.Y; y < img.Rect.Max.Y; y++ {
+		for x := img.Rect.Min.X; x < img.Rect.Max.X; x++ {
+			img.Pix[img.PixOffset(x, y)] = fb.Pixel(y/scale, x/scale)
+		}
+	}
+	return img
+}
+
+// FileName ... Returns the name of a capture of romPath taken at t, with the given extension. Only the ROM's base name (without extension) is used
+func FileName(romPath string, t time.Time, ext string) string {
+	rom := strings.TrimSuffix(filepath.Base(romPath), filepath.Ext(romPath))
+	return fmt.Sprintf("%s-%s.%s", rom, t.Format(timestampFormat), ext)
+}
+
+// create ... Creates the directory if it doesnt exist yet, and then a new file in it named after romPath and the current time. The caller must close the file
+func create(dir, romPath, ext string) (*os.File, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return os.Create(filepath.Join(dir, FileName(romPath, time.Now(), ext)))
+}
+
+// Screenshot ... Writes fb to a PNG file in dir named after romPath and the current time, with every pixel repeated scale times across and down.
+// returns the path of the file written
+func Screenshot(dir, romPath string, fb *framebuffer.Framebuffer, palette Palette, scale int) (string, error) {
+	file, err := create(dir, romPath, "png")
+	if err != nil {
+		return "", fmt.Errorf("error in capture/Screenshot(): %w", err)
+	}
+	defer file.Close()
+	if err := png.Encode(file, Image(fb, palette, scale)); err != nil {
+		return "", fmt.Errorf("error in capture/Screenshot(): %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return "", fmt.Errorf("error in capture/Screenshot(): %w", err)
+	}
+	return file.Name(), nil
+}
@@ -0,0 +1,83 @@
+package capture
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/TH3-F001/GoChip-8/chip8/internal/framebuffer"
+)
+
+// pngSignature ... The eight bytes every PNG file starts with
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// newFrame ... Returns a height by width frame with the pixel at row, col lit on both bitplanes
+func newFrame(t *testing.T, height, width, row, col int) *framebuffer.Framebuffer {
+	t.Helper()
+	fb, err := framebuffer.New(height, width)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fb.Set(0, row, col, true)
+	fb.Set(1, row, col, true)
+	return fb
+}
+
+func TestScreenshot(t *testing.T) {
+	tests := []struct {
+		name          string
+		height, width int
+		scale         int
+		wantW, wantH  int
+	}{
+		{name: "lores", height: 32, width: 64, scale: 1, wantW: 64, wantH: 32},
+		{name: "hires scaled up", height: 64, width: 128, scale: 3, wantW: 384, wantH: 192},
+		{name: "scale below 1 is 1", height: 32, width: 64, scale: 0, wantW: 64, wantH: 32},
+	}
+	palette := NewPalette(0xFFFFFF, 0x000000, nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "screenshots")
+			path, err := Screenshot(dir, "/roms/pong.ch8", newFrame(t, tt.height, tt.width, 1, 2), palette, tt.scale)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if filepath.Dir(path) != dir || filepath.Ext(path) != ".png" {
+				t.Errorf("Screenshot() wrote %s, want a .png in %s", path, dir)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.HasPrefix(data, pngSignature) {
+				t.Fatalf("file starts % x, want the PNG signature", data[:min(len(data), 8)])
+			}
+			img, err := png.Decode(bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if size := img.Bounds().Size(); size.X != tt.wantW || size.Y != tt.wantH {
+				t.Errorf("image is %dx%d, want %dx%d", size.X, size.Y, tt.wantW, tt.wantH)
+			}
+			scale := max(tt.scale, 1)
+			// the pixel lit on both bitplanes is drawn in the last colour of the palette, across the whole of its scaled square
+			if got := color.RGBAModel.Convert(img.At(2*scale+scale-1, 1*scale)); got != palette[3] {
+				t.Errorf("lit pixel is %v, want %v", got, palette[3])
+			}
+			if got := color.RGBAModel.Convert(img.At(0, 0)); got != palette[0] {
+				t.Errorf("unlit pixel is %v, want %v", got, palette[0])
+			}
+		})
+	}
+}
+
+func TestFileName(t *testing.T) {
+	at := time.Date(2024, 3, 5, 14, 7, 9, 250_000_000, time.UTC)
+	if got, want := FileName("/roms/Space Invaders.ch8", at, "gif"), "Space Invaders-20240305-140709.250.gif"; got != want {
+		t.Errorf("FileName() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,247 @@
+package capture
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"io"
+
+	"github.com/TH3-F001/GoChip-8/chip8/internal/framebuffer"
+)
+
+// Formats ... The formats gameplay can be recorded in
+const (
+	// FormatGIF ... An animated GIF. Frames are kept in memory until the recording is finished, and only the part of each frame that changed is stored
+	FormatGIF = "gif"
+	// FormatPPM ... A stream of binary PPM images, one per frame, that can be piped into encoders such as ffmpeg's image2pipe
+	FormatPPM = "ppm"
+	// FormatY4M ... A YUV4MPEG2 stream of full range 4:4:4 frames that carries its own frame rate, for encoders such as ffmpeg and x264
+	FormatY4M = "y4m"
+)
+
+// frameRate ... The number of frames the chip8 emulates per second
+const frameRate = 60
+
+// minGIFDelay ... The shortest delay between GIF frames, in 100ths of a second, that browsers show as is rather than slowing down.
+// Frames that would be shown for less are dropped
+const minGIFDelay = 2
+
+// ErrNoFrames ... Returned when a recording is finished without a single frame in it
+var ErrNoFrames = errors.New("no frames were recorded")
+
+// encoder ... Writes frames in one of the formats above. frame is the number of emulated frames since the recording started
+type encoder interface {
+	encode(fb *framebuffer.Framebuffer, frame uint64) error
+	finish(frames uint64) error
+}
+
+// Video ... Records every decimation'th emulated frame to a writer in one of the formats above. Should be instantiated using capture.NewVideo()
+// frames is the number of frames given to Frame so far. width and height are the size of the first frame, which every other frame must match
+type Video struct {
+	enc        encoder
+	decimation int
+	frames     uint64
+	width      int
+	height     int
+}
+
+// CheckFormat ... Returns an error if format isnt one of the formats above
+func CheckFormat(format string) error {
+	switch format {
+	case FormatGIF, FormatPPM, FormatY4M:
+		return nil
+	}
+	return fmt.Errorf("unknown capture format %q. must be %s, %s or %s", format, FormatGIF, FormatPPM, FormatY4M)
+}
+
+// NewVideo ... Creates a Video that writes to w in the given format, with every pixel repeated scale times across and down, and only every decimation'th frame kept
+func NewVideo(w io.Writer, format string, palette Palette, scale, decimation int) (*Video, error) {
+	if err := CheckFormat(format); err != nil {
+		return nil, fmt.Errorf("error in capture/NewVideo(): %w", err)
+	}
+	scale, decimation = max(scale, 1), max(decimation, 1)
+	v := Video{decimation: decimation}
+	switch format {
+	case FormatGIF:
+		v.enc = &gifEncoder{w: w, palette: palette, scale: scale}
+	case FormatPPM:
+		v.enc = &ppmEncoder{w: bufio.NewWriter(w), palette: palette, scale: scale}
+	case FormatY4M:
+		v.enc = newY4MEncoder(w, palette, scale, decimation)
+	}
+	return &v, nil
+}
+
+// Frame ... Records fb if it is the decimation'th frame since the last one recorded. Must be called with every emulated frame
+func (v *Video) Frame(fb *framebuffer.Framebuffer) error {
+	defer func() { v.frames++ }()
+	if v.frames%uint64(v.decimation) != 0 {
+		return nil
+	}
+	if v.frames == 0 {
+		v.width, v.height = fb.Width(), fb.Height()
+	} else if fb.Width() != v.width || fb.Height() != v.height {
+		return fmt.Errorf("error in capture/Video.Frame(): frame is %dx%d, but the recording is %dx%d", fb.Width(), fb.Height(), v.width, v.height)
+	}
+	if err := v.enc.encode(fb, v.frames); err != nil {
+		return fmt.Errorf("error in capture/Video.Frame(): %w", err)
+	}
+	return nil
+}
+
+// Close ... Finishes the recording. GIFs are only written once closed. The writer itself isnt closed
+func (v *Video) Close() error {
+	if v.frames == 0 {
+		return fmt.Errorf("error in capture/Video.Close(): %w", ErrNoFrames)
+	}
+	if err := v.enc.finish(v.frames); err != nil {
+		return fmt.Errorf("error in capture/Video.Close(): %w", err)
+	}
+	return nil
+}
+
+// #region GIF
+
+// gifEncoder ... Collects frames into an animated GIF, written by finish.
+// Frames identical to the last one stored extend how long it is shown instead of being stored again, and the rest only store the rectangle that changed.
+// last is a copy of the last frame stored, and lastFrame the emulated frame it was first shown on
+type gifEncoder struct {
+	w         io.Writer
+	palette   Palette
+	scale     int
+	anim      gif.GIF
+	last      *framebuffer.Framebuffer
+	lastFrame uint64
+}
+
+// centiseconds ... Returns the time at which an emulated frame is shown, in 100ths of a second
+func centiseconds(frame uint64) int {
+	return int((frame*100 + frameRate/2) / frameRate)
+}
+
+// encode ... Stores fb if it differs from the last frame stored, and the last frame has been shown for at least minGIFDelay
+func (g *gifEncoder) encode(fb *framebuffer.Framebuffer, frame uint64) error {
+	if g.last == nil {
+		g.anim.Config = image.Config{ColorModel: g.palette.colorPalette(), Width: fb.Width() * g.scale, Height: fb.Height() * g.scale}
+		g.add(paletted(fb, image.Rect(0, 0, fb.Width(), fb.Height()), g.palette, g.scale), frame)
+		g.last = fb.Clone()
+		return nil
+	}
+	delay := centiseconds(frame) - centiseconds(g.lastFrame)
+	if fb.Equal(g.last) || delay < minGIFDelay {
+		return nil
+	}
+	g.anim.Delay[len(g.anim.Delay)-1] = delay
+	g.add(paletted(fb, changed(g.last, fb), g.palette, g.scale), frame)
+	g.last.CopyFrom(fb)
+	return nil
+}
+
+// add ... Appends a frame that is drawn over the frames before it
+func (g *gifEncoder) add(img *image.Paletted, frame uint64) {
+	g.anim.Image = append(g.anim.Image, img)
+	g.anim.Delay = append(g.anim.Delay, 0)
+	g.anim.Disposal = append(g.anim.Disposal, gif.DisposalNone)
+	g.lastFrame = frame
+}
+
+// finish ... Shows the last frame stored until the end of the recording, and writes the GIF
+func (g *gifEncoder) finish(frames uint64) error {
+	g.anim.Delay[len(g.anim.Delay)-1] = max(centiseconds(frames)-centiseconds(g.lastFrame), minGIFDelay)
+	return gif.EncodeAll(g.w, &g.anim)
+}
+
+// changed ... Returns the smallest rectangle holding every pixel that differs between two frames of the same size. Never empty, since GIF frames cant be
+func changed(a, b *framebuffer.Framebuffer) image.Rectangle {
+	var r image.Rectangle
+	for row := 0; row < a.Height(); row++ {
+		for col := 0; col < a.Width(); col++ {
+			if a.Pixel(row, col) != b.Pixel(row, col) {
+				r = r.Union(image.Rect(col, row, col+1, row+1))
+			}
+		}
+	}
+	if r.Empty() {
+		return image.Rect(0, 0, 1, 1)
+	}
+	return r
+}
+
+//#endregion
+
+// #region Raw streams
+
+// ppmEncoder ... Writes every frame as a binary PPM image
+type ppmEncoder struct {
+	w       *bufio.Writer
+	palette Palette
+	scale   int
+}
+
+// encode ... Writes fb as a PPM image and flushes it, so that whatever is reading the stream gets every frame as soon as it is recorded
+func (p *ppmEncoder) encode(fb *framebuffer.Framebuffer, frame uint64) error {
+	fmt.Fprintf(p.w, "P6\n%d %d\n255\n", fb.Width()*p.scale, fb.Height()*p.scale)
+	forEachPixel(fb, p.scale, func(mask byte) {
+		c := p.palette[mask]
+		p.w.Write([]byte{c.R, c.G, c.B})
+	})
+	return p.w.Flush()
+}
+
+// finish ... Does nothing, since every frame has already been written
+func (p *ppmEncoder) finish(frames uint64) error {
+	return nil
+}
+
+// y4mEncoder ... Writes frames to a YUV4MPEG2 stream. The stream header is written with the first frame, once its size is known.
+// ycbcr holds the Y, Cb and Cr of each palette entry
+type y4mEncoder struct {
+	w          *bufio.Writer
+	scale      int
+	decimation int
+	ycbcr      [len(Palette{})][3]byte
+	started    bool
+}
+
+// newY4MEncoder ... Creates a y4mEncoder for the given palette. decimation sets the frame rate written in the stream header
+func newY4MEncoder(w io.Writer, palette Palette, scale, decimation int) *y4mEncoder {
+	y := y4mEncoder{w: bufio.NewWriter(w), scale: scale, decimation: decimation}
+	for i, c := range palette {
+		y.ycbcr[i][0], y.ycbcr[i][1], y.ycbcr[i][2] = color.RGBToYCbCr(c.R, c.G, c.B)
+	}
+	return &y
+}
+
+// encode ... Writes fb as a frame of three full size planes, and flushes it so that whatever is reading the stream gets every frame as soon as it is recorded
+func (y *y4mEncoder) encode(fb *framebuffer.Framebuffer, frame uint64) error {
+	if !y.started {
+		fmt.Fprintf(y.w, "YUV4MPEG2 W%d H%d F%d:%d Ip A1:1 C444 XCOLORRANGE=FULL\n", fb.Width()*y.scale, fb.Height()*y.scale, frameRate, y.decimation)
+		y.started = true
+	}
+	y.w.WriteString("FRAME\n")
+	for plane := 0; plane < 3; plane++ {
+		forEachPixel(fb, y.scale, func(mask byte) {
+			y.w.WriteByte(y.ycbcr[mask][plane])
+		})
+	}
+	return y.w.Flush()
+}
+
+// finish ... Does nothing, since every frame has already been written
+func (y *y4mEncoder) finish(frames uint64) error {
+	return nil
+}
+
+// forEachPixel ... Calls fn with the bitplanes lit in every pixel of fb repeated scale times across and down, left to right and top to bottom
+func forEachPixel(fb *framebuffer.Framebuffer, scale int, fn func(mask byte)) {
+	for y := 0; y < fb.Height()*scale; y++ {
+		for x := 0; x < fb.Width()*scale; x++ {
+			fn(fb.Pixel(y/scale, x/scale))
+		}
+	}
+}
+
+//#endregion
@@ -0,0 +1,140 @@
+package capture
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/gif"
+	"slices"
+	"testing"
+
+	"github.com/TH3-F001/GoChip-8/chip8/internal/framebuffer"
+)
+
+// record ... Records frames to a new Video in the given format, and returns what it wrote
+func record(t *testing.T, format string, scale, decimation int, frames []*framebuffer.Framebuffer) []byte {
+	t.Helper()
+	var out bytes.Buffer
+	v, err := NewVideo(&out, format, NewPalette(0xFFFFFF, 0x000000, nil), scale, decimation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, fb := range frames {
+		if err := v.Frame(fb); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := v.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return out.Bytes()
+}
+
+func TestPPM(t *testing.T) {
+	frames := []*framebuffer.Framebuffer{newFrame(t, 32, 64, 1, 2), newFrame(t, 32, 64, 0, 0)}
+	out := record(t, FormatPPM, 2, 1, frames)
+	header := []byte("P6\n128 64\n255\n")
+	size := len(header) + 128*64*3
+	if len(out) != 2*size {
+		t.Fatalf("wrote %d bytes, want 2 images of %d", len(out), size)
+	}
+	palette := NewPalette(0xFFFFFF, 0x000000, nil)
+	for i := range frames {
+		img := out[i*size : (i+1)*size]
+		if !bytes.HasPrefix(img, header) {
+			t.Errorf("image %d starts %q, want %q", i, img[:len(header)], header)
+		}
+	}
+	// the pixel at row 1, column 2 is drawn at rows 2 and 3, columns 4 and 5
+	lit := palette[3]
+	for _, at := range []struct{ x, y int }{{4, 2}, {5, 3}} {
+		offset := len(header) + (at.y*128+at.x)*3
+		if got := out[offset : offset+3]; !bytes.Equal(got, []byte{lit.R, lit.G, lit.B}) {
+			t.Errorf("pixel %d,%d = % x, want the colour of both bitplanes", at.x, at.y, got)
+		}
+	}
+}
+
+func TestY4M(t *testing.T) {
+	var frames []*framebuffer.Framebuffer
+	for i := 0; i < 5; i++ {
+		frames = append(frames, newFrame(t, 64, 128, 0, i))
+	}
+	// only every second frame is kept, so the stream runs at 30fps
+	out := record(t, FormatY4M, 1, 2, frames)
+	header := "YUV4MPEG2 W128 H64 F60:2 Ip A1:1 C444 XCOLORRANGE=FULL\n"
+	if !bytes.HasPrefix(out, []byte(header)) {
+		t.Fatalf("stream starts %q, want %q", out[:min(len(out), len(header))], header)
+	}
+	frame := len("FRAME\n") + 3*128*64
+	if len(out) != len(header)+3*frame {
+		t.Fatalf("wrote %d bytes, want a header and 3 frames of %d", len(out), frame)
+	}
+	for i := 0; i < 3; i++ {
+		body := out[len(header)+i*frame:]
+		if !bytes.HasPrefix(body, []byte("FRAME\n")) {
+			t.Errorf("frame %d starts %q, want FRAME", i, body[:6])
+		}
+		// Y of the pixel lit in the kept frame i*2, and of a pixel left black
+		y := body[len("FRAME\n"):]
+		if y[i*2] == 0 || y[i*2+1] != 0 {
+			t.Errorf("frame %d has luma % x, want the pixel at column %d lit and the next black", i, y[i*2:i*2+2], i*2)
+		}
+	}
+}
+
+func TestGIF(t *testing.T) {
+	// a still first frame for half a second, a change, a change the frame after that is kept, and another the frame after that is too soon to be shown
+	var frames []*framebuffer.Framebuffer
+	for i := 0; i < 30; i++ {
+		frames = append(frames, newFrame(t, 32, 64, 1, 2))
+	}
+	frames = append(frames, newFrame(t, 32, 64, 5, 6), newFrame(t, 32, 64, 10, 20), newFrame(t, 32, 64, 0, 0))
+	out := record(t, FormatGIF, 2, 1, frames)
+	if !bytes.HasPrefix(out, []byte("GIF89a")) {
+		t.Fatalf("file starts %q, want GIF89a", out[:min(len(out), 6)])
+	}
+	anim, err := gif.DecodeAll(bytes.NewReader(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if anim.Config.Width != 128 || anim.Config.Height != 64 {
+		t.Errorf("GIF is %dx%d, want 128x64", anim.Config.Width, anim.Config.Height)
+	}
+	if want := []int{50, 2, 3}; !slices.Equal(anim.Delay, want) {
+		t.Errorf("delays = %v, want %v", anim.Delay, want)
+	}
+	// only the rectangle that changed is stored, covering the pixel lit and the one unlit
+	want := []image.Rectangle{image.Rect(0, 0, 128, 64), image.Rect(4, 2, 14, 12), image.Rect(12, 10, 42, 22)}
+	if len(anim.Image) != len(want) {
+		t.Fatalf("GIF has %d images, want %d", len(anim.Image), len(want))
+	}
+	for i, img := range anim.Image {
+		if img.Bounds() != want[i] {
+			t.Errorf("image %d covers %v, want %v", i, img.Bounds(), want[i])
+		}
+	}
+}
+
+func TestVideoErrors(t *testing.T) {
+	if _, err := NewVideo(&bytes.Buffer{}, "avi", Palette{}, 1, 1); err == nil {
+		t.Error("NewVideo() of an unknown format error = nil, want an error")
+	}
+	for _, format := range []string{FormatGIF, FormatPPM, FormatY4M} {
+		t.Run(format, func(t *testing.T) {
+			v, err := NewVideo(&bytes.Buffer{}, format, Palette{}, 1, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := v.Close(); !errors.Is(err, ErrNoFrames) {
+				t.Errorf("Close() without frames error = %v, want ErrNoFrames", err)
+			}
+			if err := v.Frame(newFrame(t, 32, 64, 0, 0)); err != nil {
+				t.Fatal(err)
+			}
+			if err := v.Frame(newFrame(t, 64, 128, 0, 0)); err == nil {
+				t.Errorf("Frame() of a %s frame of another size error = nil, want an error", format)
+			}
+		})
+	}
+}
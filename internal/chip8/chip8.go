@@ -1,52 +1,118 @@
 package chip8
 
 import (
-	"log"
+	"errors"
+	"fmt"
+	"math"
 	"math/rand/v2"
-	"time"
 
 	"github.com/TH3-F001/GoChip-8/chip8/internal/config"
-	"github.com/TH3-F001/GoChip-8/chip8/internal/dataconverter"
+	"github.com/TH3-F001/GoChip-8/chip8/internal/framebuffer"
 	"github.com/TH3-F001/GoChip-8/chip8/internal/io"
-	"github.com/TH3-F001/gotoolshed/stack"
+)
+
+const (
+	// fontAddress ... The memory address the font is loaded into
+	fontAddress uint16 = 0x50
+	// fontCharHeight ... The number of bytes that make up a single font character
+	fontCharHeight uint16 = 5
+	// bigFontAddress ... The memory address the SUPER-CHIP 8x10 font is loaded into (directly after the small font)
+	bigFontAddress uint16 = 0xA0
+	// bigFontCharHeight ... The number of bytes that make up a single big font character
+	bigFontCharHeight uint16 = 10
+	// numPlanes ... The number of XO-CHIP bitplanes
+	numPlanes = framebuffer.NumPlanes
+	// loresWidth ... The width of the display in low resolution mode. the display is scaled up to the backend's width from here
+	loresWidth = 64
+	// programAddress ... The memory address programs are loaded into, and where execution starts
+	programAddress uint16 = 0x200
 )
 
 // Chip8 ... Struct that holds all of Chip8's registers, timers, and state variables
 type Chip8 struct {
-	// Chip8.MEM ... A 4KB byte arrow for storing the chip-8s working memory
-	MEM [4096]byte
+	// Chip8.MEM ... A 64KB byte array for storing the chip-8s working memory. Only the first 4KB are addressable outside of XO-CHIP mode
+	MEM [0x10000]byte
 	// Chip8.STK ... A 16 element array of 16-bit memory addresses. Used to store previous memory address before jumping or calling a subroutine
-	STK *stack.Stack[uint16]
+	STK [16]uint16
 	// Chip8.V ... an array of 16 byte-long variable registers for storing general purpose data
 	V [16]byte
 	// Chip8.PC ... a 16-bit Program Counter that stores the index of the currently running instruction in memory
 	PC uint16
-	// Chip8.SP ... a 16-bit Stack pointer that im not sure i've used yet, or why id use it... recursion maybe
+	// Chip8.SP ... a 16-bit Stack pointer that holds the number of addresses currently pushed onto STK
 	SP uint16
 	// Chip8.I ... a 16-bit index register. Used to point at locations in memory
 	I uint16
+	// RPL ... SUPER-CHIP user flags (the HP-48's RPL registers) used by Fx75 and Fx85 to persist registers
+	RPL [16]byte
+	// AudioPattern ... (XO-CHIP) A 128-bit 1-bit audio sample loaded by F002, played while ST is non-zero
+	AudioPattern [16]byte
+	// Pitch ... (XO-CHIP) The playback pitch of AudioPattern set by Fx3A. 64 is the default 4000Hz sample rate
+	Pitch byte
 	// DT ... A byte-long Delay timer. Decremented 60 times a second until reaching zero
 	DT byte
 	// ST ... A byte-long timer similar to DT. Decremented 60 times a second until reaching zero. gives off a beeping sound as long as timer isnt zero
 	ST byte
+	// Frame ... The number of 60Hz frames that have been emulated by RunFrame
+	Frame uint64
+	// Instructions ... The number of instructions that have been executed by Step
+	Instructions uint64
+	// Quirks ... The platform behaviours and instruction set extensions the chip was initialized with
+	Quirks config.Quirks
+	// CyclesPerFrame ... When non-zero, RunFrame uses the COSMAC VIP timing model: every frame gets this many machine cycles to spend on instructions
+	// instead of a fixed instruction count. see timing.go
+	CyclesPerFrame int
+	// ErrorPolicy ... Decides what RunFrame does when an instruction fails. Defaults to Halt
+	ErrorPolicy ErrorPolicy
+	// TrapFunc ... Called by RunFrame with the failed instruction's error when ErrorPolicy is Trap
+	TrapFunc func(*Chip8, *ExecError) error
 
 	// dw ... Display Width: holds the max width of the display
 	dw byte
 	// dh ... Display Height: holds the max height of the display
 	dh byte
-	// inout ... the Chip's local reference to the io.IO object
-	inout io.IO
-	// ticker ... a 60Hz ticker for the delay and sound timers
-	ticker *time.Ticker
+	// hires ... true when the SUPER-CHIP high resolution mode is active. In low resolution mode each pixel is scaled to fill the display
+	hires bool
+	// planes ... (XO-CHIP) bitmask of the bitplanes selected by Fn01 that drawing, clearing and scrolling act on
+	planes byte
+	// fb ... the display. drawn to by the chip and handed to display once a frame has been completed
+	fb *framebuffer.Framebuffer
+	// dirty ... set whenever fb changes, so that RunFrame only presents frames that have something new to show
+	dirty bool
+	// keys ... the Chip's reference to keypad as an io.KeyStateIO. nil if the keypad only reports the last key pressed
+	keys io.KeyStateIO
+	// framers ... the display and keypad, if they are io.FrameIOs. each is only held once, even when the display and keypad are the same backend
+	framers []io.FrameIO
+	// cycles ... the machine cycles left in the current frame under the VIP timing model. negative when the last frame overran
+	cycles int
+	// vblankWait ... set by Dxyn when the DisplayWait quirk is enabled so that RunFrame ends the frame early
+	vblankWait bool
+	// exited ... set by 00FD to signal that the program has asked the interpreter to exit
+	exited bool
+	// hooks ... callbacks registered to observe the chip. see hooks.go
+	hooks hooks
+	// display ... the Chip's local reference to the io.Display it presents frames to. nil if frames are only read with Framebuffer
+	display io.Display
+	// keypad ... the Chip's local reference to the io.Keypad it reads keys from. nil if no key is ever pressed
+	keypad io.Keypad
+	// audio ... the Chip's local reference to the io.Audio that plays its beep. nil if the chip is silent
+	audio io.Audio
+	// pattern ... the Chip's reference to audio as an io.PatternIO. nil if the audio can only play the plain beep
+	pattern io.PatternIO
+	// random ... the random source used by RND. saved and restored along with the rest of the machine state
+	random Random
 
-	// RightShiftFunc ... A function pointer that is assigned on initialization based on whether the CosmacCompatible flag is true
+	// RightShiftFunc ... A function pointer that is assigned on initialization based on the Quirks profile
 	RightShiftFunc func(*Chip8, uint16)
-	// LeftShiftFunc ... A function pointer that is assigned on initialization based on whether the CosmacCompatible flag is true
+	// LeftShiftFunc ... A function pointer that is assigned on initialization based on the Quirks profile
 	LeftShiftFunc func(*Chip8, uint16)
-	// JumpbFunc ... A function pointer that is assigned on initialization based on whether the CosmacCompatible flag is true
+	// JumpbFunc ... A function pointer that is assigned on initialization based on the Quirks profile
 	JumpbFunc func(*Chip8, uint16)
-	// YCoordFunc ... A function pointer that is assigned on initialization based on whether the CosmacCompatible flag is true
+	// YCoordFunc ... A function pointer that is assigned on initialization based on the Quirks profile
 	YCoordFunc func(*Chip8, byte, byte) int
+	// StoreRegsFunc ... A function pointer that is assigned on initialization based on the Quirks profile
+	StoreRegsFunc func(*Chip8, uint16) error
+	// LoadRegsFunc ... A function pointer that is assigned on initialization based on the Quirks profile
+	LoadRegsFunc func(*Chip8, uint16) error
 }
 
 // #region ShiftFunc Implementations
@@ -94,104 +160,340 @@ func jumpbSuper(chip *Chip8, opcode uint16) {
 
 //#endregion
 
-// #region YCoordFunc Implementations
+// #region RegsFunc Implementations
+func storeRegsCosmac(chip *Chip8, opcode uint16) error {
+	x := getOpcodeNibble(opcode, 1)
+	if err := chip.checkMem(chip.I, int(x)+1); err != nil {
+		return err
+	}
+	for i := byte(0); i <= x; i++ {
+		chip.writeMem(chip.I, chip.V[i])
+		chip.I++
+	}
+	return nil
+}
+
+func storeRegsSuper(chip *Chip8, opcode uint16) error {
+	x := getOpcodeNibble(opcode, 1)
+	if err := chip.checkMem(chip.I, int(x)+1); err != nil {
+		return err
+	}
+	for i := byte(0); i <= x; i++ {
+		chip.writeMem(chip.I+uint16(i), chip.V[i])
+	}
+	return nil
+}
+
+func loadRegsCosmac(chip *Chip8, opcode uint16) error {
+	x := getOpcodeNibble(opcode, 1)
+	if err := chip.checkMem(chip.I, int(x)+1); err != nil {
+		return err
+	}
+	for i := byte(0); i <= x; i++ {
+		chip.V[i] = chip.readMem(chip.I)
+		chip.I++
+	}
+	return nil
+}
+
+func loadRegsSuper(chip *Chip8, opcode uint16) error {
+	x := getOpcodeNibble(opcode, 1)
+	if err := chip.checkMem(chip.I, int(x)+1); err != nil {
+		return err
+	}
+	for i := byte(0); i <= x; i++ {
+		chip.V[i] = chip.readMem(chip.I + uint16(i))
+	}
+	return nil
+}
+
+//#endregion
+
+// #region CoordFunc Implementations
 func getYCoordWrapped(chip *Chip8, yStart byte, addedRows byte) int {
-	return int((yStart + addedRows) % chip.dh)
+	return (int(yStart) + int(addedRows)) % chip.height()
 }
 
 func getYCoord(chip *Chip8, yStart byte, addedRows byte) int {
-	return int(yStart + addedRows)
+	return int(yStart) + int(addedRows)
 }
 
 //#endregion
 
 // #region Helper Functions
 
-// Returns a byte of a chip8 opcode as defined by index. index may only be values 0 and 1. all other values are out of bounds
+// Returns a byte of a chip8 opcode as defined by index. index may only be values 0 and 1. other values wrap around
 func getOpcodeByte(opcode uint16, index byte) byte {
-	switch index {
-	case 0:
-		return byte((0xFF00 & opcode) >> 8)
-	case 1:
-		return byte((0x00FF & opcode))
-	default:
-		log.Fatal("Fatal: Attempt to access opcode byte is out of bounds at index", index)
-	}
-	return 0
+	shift := 8 - 8*(index&0x1)
+	return byte(opcode >> shift)
 }
 
-// Returns a nibble of a chip8 opcode as defined by index. index may only be a value between 0 and 3. all other values are out of bounds.
+// Returns a nibble of a chip8 opcode as defined by index. index may only be a value between 0 and 3. other values wrap around
 func getOpcodeNibble(opcode uint16, index byte) byte {
-	switch index {
-	case 0:
-		return byte((0xF000 & opcode) >> 12)
-	case 1:
-		return byte((0x0F00 & opcode) >> 8)
-	case 2:
-		return byte((0x00F0 & opcode) >> 4)
-	case 3:
-		return byte((0x000F & opcode))
-	default:
-		log.Fatal("Fatal: Attempt to access opcode nibble is out of bounds at index", index)
+	shift := 12 - 4*(index&0x3)
+	return byte(opcode>>shift) & 0xF
+}
+
+// memSize ... Returns the number of bytes of memory addressable by the chip's instruction set
+func (chip *Chip8) memSize() int {
+	if chip.Quirks.XOChip {
+		return len(chip.MEM)
 	}
-	return 0
+	return 0x1000
+}
+
+// checkMem ... Returns ErrMemoryOutOfBounds if length bytes starting at addr dont fit in addressable memory
+func (chip *Chip8) checkMem(addr uint16, length int) error {
+	if int(addr)+length > chip.memSize() {
+		return ErrMemoryOutOfBounds
+	}
+	return nil
+}
+
+// scale ... Returns how many display pixels wide and tall a single chip8 pixel is in the current resolution mode
+func (chip *Chip8) scale() int {
+	if chip.hires || int(chip.dw) <= loresWidth {
+		return 1
+	}
+	return int(chip.dw) / loresWidth
+}
+
+// width ... Returns the width of the display in chip8 pixels for the current resolution mode
+func (chip *Chip8) width() int {
+	return int(chip.dw) / chip.scale()
+}
+
+// height ... Returns the height of the display in chip8 pixels for the current resolution mode
+func (chip *Chip8) height() int {
+	return int(chip.dh) / chip.scale()
+}
+
+// scaleRow ... Doubles every pixel of a sprite row of the given width s times over, so that it covers the display pixels the chip8 pixels are scaled to.
+// s may only be 1 or 2
+func scaleRow(pixels uint64, width, s int) uint64 {
+	if s == 1 {
+		return pixels
+	}
+	var scaled uint64
+	for i := width - 1; i >= 0; i-- {
+		scaled = scaled<<2 | (pixels>>i&1)*0b11
+	}
+	return scaled
+}
+
+// planeSelected ... Returns true if the given bitplane is selected for drawing
+func (chip *Chip8) planeSelected(plane int) bool {
+	return chip.planes&(1<<plane) != 0
+}
+
+// clearDisplay ... Turns off every pixel on the selected bitplanes
+func (chip *Chip8) clearDisplay() {
+	for plane := 0; plane < numPlanes; plane++ {
+		if chip.planeSelected(plane) {
+			chip.fb.Clear(plane)
+		}
+	}
+	chip.dirty = true
+}
+
+// scroll ... Moves the contents of the selected bitplanes by the given number of chip8 pixels. pixels scrolled in from the edges are turned off.
+// With the ScrollHalfPixels quirk, low resolution mode scrolls by display pixels instead, which are half the size of a chip8 pixel
+func (chip *Chip8) scroll(dRow, dCol int) {
+	s := chip.scale()
+	if !chip.Quirks.ScrollHalfPixels || s == 1 {
+		dRow, dCol = dRow*s, dCol*s
+	}
+	for plane := 0; plane < numPlanes; plane++ {
+		if chip.planeSelected(plane) {
+			chip.fb.Scroll(plane, dRow, dCol)
+		}
+	}
+	chip.dirty = true
+}
+
+// present ... Hands the framebuffer to the display if it has changed since it was last presented
+func (chip *Chip8) present() error {
+	if !chip.dirty || chip.display == nil {
+		return nil
+	}
+	chip.dirty = false
+	return chip.display.Present(chip.fb)
+}
+
+// tickTimers ... Decrements the delay and sound timers. Called once per emulated 60Hz frame
+func (chip *Chip8) tickTimers() {
+	if chip.DT > 0 {
+		chip.DT--
+	}
+	if chip.ST > 0 {
+		chip.ST--
+	}
+	chip.notifySound()
+}
+
+// keyDown ... Returns true if the given key is held down. Keypads that dont track key state only report the last key pressed
+func (chip *Chip8) keyDown(key byte) bool {
+	if chip.keys != nil {
+		return chip.keys.KeyDown(key)
+	}
+	if chip.keypad == nil {
+		return false
+	}
+	pressed, _ := chip.keypad.ListenNow()
+	return pressed == key
+}
+
+// skip ... Skips the next instruction. In XO-CHIP mode the double-wide F000 NNNN instruction is skipped in its entirety
+func (chip *Chip8) skip() {
+	if chip.Quirks.XOChip && chip.MEM[chip.PC] == 0xF0 && chip.MEM[chip.PC+1] == 0x00 {
+		chip.PC += 2
+	}
+	chip.PC += 2
 }
 
 //#endregion
 
-// New ... Maps configurable functions to the chip8's function pointers, and gives chip8 a local reference to an io.IO instance
-func New(conf config.Config, inout io.IO, program, font []byte, displayHeight, displayWidth byte) *Chip8 {
+// New ... Maps the Quirks profile's behaviours to the chip8's function pointers, and gives chip8 local references to its display, keypad and audio.
+// Any of them can be nil, and a single backend can be given as more than one of them.
+// random is the source RND draws from. if nil, a PCGRandom with a random seed is used.
+// returns an error if the display is too large for a framebuffer
+func New(quirks config.Quirks, display io.Display, keypad io.Keypad, audio io.Audio, random Random, program, font, bigFont []byte, displayHeight, displayWidth byte) (*Chip8, error) {
+	fb, err := framebuffer.New(int(displayHeight), int(displayWidth))
+	if err != nil {
+		return nil, fmt.Errorf("error in chip8/New(): %w", err)
+	}
 	var chip Chip8
-	if conf.CosmacCompatible {
+	chip.applyQuirks(quirks)
+	chip.planes = 1
+	chip.fb = fb
+	chip.dirty = true
+	chip.display, chip.keypad, chip.audio = display, keypad, audio
+	if keys, ok := keypad.(io.KeyStateIO); ok {
+		chip.keys = keys
+	}
+	if pattern, ok := audio.(io.PatternIO); ok {
+		chip.pattern = pattern
+	}
+	if framer, ok := display.(io.FrameIO); ok {
+		chip.framers = append(chip.framers, framer)
+	}
+	if framer, ok := keypad.(io.FrameIO); ok && (len(chip.framers) == 0 || chip.framers[0] != framer) {
+		chip.framers = append(chip.framers, framer)
+	}
+	if random == nil {
+		random = NewPCGRandom(rand.Uint64())
+	}
+	chip.random = random
+
+	copy(chip.MEM[fontAddress:], font)
+	copy(chip.MEM[bigFontAddress:], bigFont)
+	copy(chip.MEM[programAddress:], program)
+	chip.PC = programAddress
+	chip.Pitch = 64
+	chip.dh = displayHeight
+	chip.dw = displayWidth
+
+	return &chip, nil
+}
+
+// applyQuirks ... Maps the Quirks profile's behaviours to the chip8's function pointers
+func (chip *Chip8) applyQuirks(quirks config.Quirks) {
+	if quirks.ShiftVY {
 		chip.RightShiftFunc = rightShiftCosmac
 		chip.LeftShiftFunc = leftShiftCosmac
-		chip.JumpbFunc = jumpbCosmac
 	} else {
 		chip.RightShiftFunc = rightShiftSuper
 		chip.LeftShiftFunc = leftShiftSuper
+	}
+	if quirks.JumpVX {
 		chip.JumpbFunc = jumpbSuper
+	} else {
+		chip.JumpbFunc = jumpbCosmac
 	}
-	if conf.VerticalWrapping {
-		chip.YCoordFunc = getYCoordWrapped
+	if quirks.MemoryIncrement {
+		chip.StoreRegsFunc = storeRegsCosmac
+		chip.LoadRegsFunc = loadRegsCosmac
 	} else {
+		chip.StoreRegsFunc = storeRegsSuper
+		chip.LoadRegsFunc = loadRegsSuper
+	}
+	if quirks.ClipY {
 		chip.YCoordFunc = getYCoord
+	} else {
+		chip.YCoordFunc = getYCoordWrapped
 	}
-	chip.inout = inout
-	chip.STK = stack.New[uint16](32)
+	chip.Quirks = quirks
+}
 
-	copy(chip.MEM[0x50:], font)
-	copy(chip.MEM[0x200:], program)
-	chip.PC = 0x200
-	chip.ST, chip.DT = 255, 255
-	chip.dh = displayHeight
-	chip.dw = displayWidth
+// AudioSampleRate ... Returns the rate in Hz that the bits of AudioPattern should be played back at for the current Pitch
+func (chip *Chip8) AudioSampleRate() float64 {
+	return 4000 * math.Pow(2, (float64(chip.Pitch)-64)/48)
+}
 
-	chip.ticker = time.NewTicker(time.Second / 60)
-	return &chip
+// Exited ... Returns true once the running program has executed 00FD (EXIT)
+func (chip *Chip8) Exited() bool {
+	return chip.exited
 }
 
-// Terminate ... Terminates hanging chip8 resources
-func (chip *Chip8) Terminate() {
-	chip.ticker.Stop()
+// Display ... Returns every bitplane of the display packed into one bit per display pixel, row by row. Used to compare or hash the display's contents
+func (chip *Chip8) Display() []byte {
+	return chip.fb.Pack()
+}
+
+// Framebuffer ... Returns the chip's display. It is drawn to by every instruction, so it should only be read between instructions and never written to
+func (chip *Chip8) Framebuffer() *framebuffer.Framebuffer {
+	return chip.fb
 }
 
 // #region OpCodes
 
-// CLS ...00E0: Clears the screen using the provided IO interface.
+// SCD ...00Cn: (SUPER-CHIP) Scrolls the display down by n pixels.
+func (chip *Chip8) SCD(opcode uint16) {
+	n := int(getOpcodeNibble(opcode, 3))
+	chip.scroll(n, 0)
+}
+
+// CLS ...00E0: Clears the screen.
 func (chip *Chip8) CLS() {
-	px := chip.inout.GetPixels()
-	for row := range px {
-		for col := range px[row] {
-			chip.inout.SetPixel(col, row, false)
-		}
+	chip.clearDisplay()
+}
+
+// RET ...00EE: Pops the last memory address from the stack and updates the program counter with this address. returns ErrStackUnderflow if the stack is empty
+func (chip *Chip8) RET() error {
+	if chip.SP == 0 {
+		return ErrStackUnderflow
 	}
-	chip.inout.Refresh()
+	chip.SP--
+	chip.PC = chip.STK[chip.SP]
+	return nil
+}
+
+// SCR ...00FB: (SUPER-CHIP) Scrolls the display right by 4 pixels.
+func (chip *Chip8) SCR() {
+	chip.scroll(0, 4)
+}
+
+// SCL ...00FC: (SUPER-CHIP) Scrolls the display left by 4 pixels.
+func (chip *Chip8) SCL() {
+	chip.scroll(0, -4)
+}
+
+// EXIT ...00FD: (SUPER-CHIP) Flags the interpreter to stop running the program.
+func (chip *Chip8) EXIT() {
+	chip.exited = true
+	chip.PC -= 2
 }
 
-// RET ...00EE: Pops the last memory address from the stack and updates the program counter with this address.
-func (chip *Chip8) RET() {
-	address, _ := chip.STK.Pop()
-	chip.PC = address
+// LOW ...00FE: (SUPER-CHIP) Switches the display to low resolution mode and clears it.
+func (chip *Chip8) LOW() {
+	chip.hires = false
+	chip.CLS()
+}
+
+// HIGH ...00FF: (SUPER-CHIP) Switches the display to high resolution mode and clears it.
+func (chip *Chip8) HIGH() {
+	chip.hires = true
+	chip.CLS()
 }
 
 // JP ...1nnn: Updates the program counter to the address specified in the last three nibbles of the opcode.
@@ -199,10 +501,15 @@ func (chip *Chip8) JP(opcode uint16) {
 	chip.PC = opcode & 0x0FFF
 }
 
-// CALL ...2nnn: Pushes the current program counter value onto the stack and then updates the program counter to the address specified in the opcode.
-func (chip *Chip8) CALL(opcode uint16) {
-	chip.STK.Push(chip.PC)
+// CALL ...2nnn: Pushes the current program counter value onto the stack and then updates the program counter to the address specified in the opcode. returns ErrStackOverflow if the stack is full
+func (chip *Chip8) CALL(opcode uint16) error {
+	if int(chip.SP) >= len(chip.STK) {
+		return ErrStackOverflow
+	}
+	chip.STK[chip.SP] = chip.PC
+	chip.SP++
 	chip.PC = opcode & 0x0FFF
+	return nil
 }
 
 // SE3 ...3xkk: Compares the value at variable register V[x] with byte; if equal, increments the program counter by one instruction.
@@ -210,7 +517,7 @@ func (chip *Chip8) SE3(opcode uint16) {
 	x := getOpcodeNibble(opcode, 1)
 	kk := getOpcodeByte(opcode, 1)
 	if chip.V[x] == kk {
-		chip.PC += 2
+		chip.skip()
 	}
 }
 
@@ -219,7 +526,7 @@ func (chip *Chip8) SNE4(opcode uint16) {
 	x := getOpcodeNibble(opcode, 1)
 	kk := getOpcodeByte(opcode, 1)
 	if chip.V[x] != kk {
-		chip.PC += 2
+		chip.skip()
 	}
 }
 
@@ -228,8 +535,48 @@ func (chip *Chip8) SE5(opcode uint16) {
 	x := getOpcodeNibble(opcode, 1)
 	y := getOpcodeNibble(opcode, 2)
 	if chip.V[x] == chip.V[y] {
-		chip.PC += 2
+		chip.skip()
+	}
+}
+
+// LD52 ...5xy2: (XO-CHIP) Stores V[x] through V[y] in memory starting at I, without modifying I. If x is greater than y the registers are stored in reverse order.
+func (chip *Chip8) LD52(opcode uint16) error {
+	x := int(getOpcodeNibble(opcode, 1))
+	y := int(getOpcodeNibble(opcode, 2))
+	step := 1
+	if x > y {
+		step = -1
+	}
+	if err := chip.checkMem(chip.I, (y-x)*step+1); err != nil {
+		return err
+	}
+	for i, reg := 0, x; ; i, reg = i+1, reg+step {
+		chip.writeMem(chip.I+uint16(i), chip.V[reg])
+		if reg == y {
+			break
+		}
 	}
+	return nil
+}
+
+// LD53 ...5xy3: (XO-CHIP) Loads V[x] through V[y] from memory starting at I, without modifying I. If x is greater than y the registers are loaded in reverse order.
+func (chip *Chip8) LD53(opcode uint16) error {
+	x := int(getOpcodeNibble(opcode, 1))
+	y := int(getOpcodeNibble(opcode, 2))
+	step := 1
+	if x > y {
+		step = -1
+	}
+	if err := chip.checkMem(chip.I, (y-x)*step+1); err != nil {
+		return err
+	}
+	for i, reg := 0, x; ; i, reg = i+1, reg+step {
+		chip.V[reg] = chip.readMem(chip.I + uint16(i))
+		if reg == y {
+			break
+		}
+	}
+	return nil
 }
 
 // LD6 ...6xkk: Assigns byte kk to variable register V[x].
@@ -253,25 +600,34 @@ func (chip *Chip8) LD8(opcode uint16) {
 	chip.V[x] = chip.V[y]
 }
 
-// OR ...8xy1: Performs a bitwise OR between V[x] and V[y], and stores the result in V[x].
+// OR ...8xy1: Performs a bitwise OR between V[x] and V[y], and stores the result in V[x]. Resets V[F] if the VFReset quirk is enabled.
 func (chip *Chip8) OR(opcode uint16) {
 	x := getOpcodeNibble(opcode, 1)
 	y := getOpcodeNibble(opcode, 2)
 	chip.V[x] = chip.V[x] | chip.V[y]
+	if chip.Quirks.VFReset {
+		chip.V[0xF] = 0
+	}
 }
 
-// AND ...8xy2: Performs a bitwise AND between V[x] and V[y], and stores the result in V[x].
+// AND ...8xy2: Performs a bitwise AND between V[x] and V[y], and stores the result in V[x]. Resets V[F] if the VFReset quirk is enabled.
 func (chip *Chip8) AND(opcode uint16) {
 	x := getOpcodeNibble(opcode, 1)
 	y := getOpcodeNibble(opcode, 2)
 	chip.V[x] = chip.V[x] & chip.V[y]
+	if chip.Quirks.VFReset {
+		chip.V[0xF] = 0
+	}
 }
 
-// XOR ...8xy3: Performs a bitwise XOR between V[x] and V[y], and stores the result in V[x].
+// XOR ...8xy3: Performs a bitwise XOR between V[x] and V[y], and stores the result in V[x]. Resets V[F] if the VFReset quirk is enabled.
 func (chip *Chip8) XOR(opcode uint16) {
 	x := getOpcodeNibble(opcode, 1)
 	y := getOpcodeNibble(opcode, 2)
 	chip.V[x] = chip.V[x] ^ chip.V[y]
+	if chip.Quirks.VFReset {
+		chip.V[0xF] = 0
+	}
 }
 
 // ADD8 ...8xy4: Adds V[y] to V[x]. If the addition results in overflow, sets V[F] to 1; otherwise, sets it to 0.
@@ -300,7 +656,7 @@ func (chip *Chip8) SUB(opcode uint16) {
 	chip.V[x] = diff
 }
 
-// SHR ...8xy6: Shifts V[x] right by one bit. If the CosmacCompatibility configuration is true, copies V[y] to V[x] first.
+// SHR ...8xy6: Shifts V[x] right by one bit. If the ShiftVY quirk is enabled, copies V[y] to V[x] first.
 func (chip *Chip8) SHR(opcode uint16) {
 	chip.RightShiftFunc(chip, opcode)
 }
@@ -318,7 +674,7 @@ func (chip *Chip8) SUBN(opcode uint16) {
 	chip.V[x] = diff
 }
 
-// SHL ...8xyE: Shifts V[x] left by one bit. If the CosmacCompatibility configuration is true, copies V[y] to V[x] first.
+// SHL ...8xyE: Shifts V[x] left by one bit. If the ShiftVY quirk is enabled, copies V[y] to V[x] first.
 func (chip *Chip8) SHL(opcode uint16) {
 	chip.LeftShiftFunc(chip, opcode)
 }
@@ -328,7 +684,7 @@ func (chip *Chip8) SNE9(opcode uint16) {
 	x := getOpcodeNibble(opcode, 1)
 	y := getOpcodeNibble(opcode, 2)
 	if chip.V[x] != chip.V[y] {
-		chip.PC += 2
+		chip.skip()
 	}
 }
 
@@ -337,7 +693,7 @@ func (chip *Chip8) LDa(opcode uint16) {
 	chip.I = opcode & 0x0FFF
 }
 
-// JPb ...Bnnn: Based on the configuration, either adds the value at V0 to the address and loads it into the program counter or uses the address offset by the value at VX.
+// JPb ...Bnnn: Based on the JumpVX quirk, either adds the value at V0 to the address and loads it into the program counter or uses the address offset by the value at VX.
 func (chip *Chip8) JPb(opcode uint16) {
 	chip.JumpbFunc(chip, opcode)
 }
@@ -346,100 +702,356 @@ func (chip *Chip8) JPb(opcode uint16) {
 func (chip *Chip8) RND(opcode uint16) {
 	x := getOpcodeNibble(opcode, 1)
 	nn := getOpcodeByte(opcode, 1)
-	chip.V[x] = byte(rand.Uint32()) & nn
+	chip.V[x] = chip.random.Byte() & nn
 }
 
 // DRW ...Dxyn: XORs a sprite onto the screen at the coordinate (V[x], V[y]) that has a width of 8 pixels and a height of n pixels, based on the data starting at the address stored in I.
-func (chip *Chip8) DRW(opcode uint16) {
+// With SUPER-CHIP enabled, Dxy0 draws a 16x16 sprite made up of two bytes per row.
+func (chip *Chip8) DRW(opcode uint16) error {
 	x := getOpcodeNibble(opcode, 1)
 	y := getOpcodeNibble(opcode, 2)
 	n := int(getOpcodeNibble(opcode, 3))
-	if y >= byte(len(chip.V)) || x >= byte(len(chip.V)) {
-		log.Fatalf("Fatal: out of bounds error in draw: X:%x Y:%x", x, y)
+
+	spriteWidth, bytesPerRow := 8, 1
+	if n == 0 && chip.Quirks.SuperChip {
+		n, spriteWidth, bytesPerRow = 16, 16, 2
+	}
+	spriteLen := 0
+	for plane := 0; plane < numPlanes; plane++ {
+		if chip.planeSelected(plane) {
+			spriteLen += n * bytesPerRow
+		}
+	}
+	if err := chip.checkMem(chip.I, spriteLen); err != nil {
+		return err
 	}
 
-	xStart := chip.V[x] & (chip.dw - 1)
-	yStart := chip.V[y] & (chip.dh - 1)
-	chip.V[0xF] = 0
-	for i := 0; i < n; i++ { // for each row in the sprite
-		yCoord := chip.YCoordFunc(chip, yStart, byte(i))
-		spriteRow := chip.MEM[chip.I+uint16(i)]
-		for j := 0; j < 8; j++ { // for each bit in the sprite (left to right)
-			xCoord := (int(xStart) + j) % int(chip.dw)
-			spritePxl := (spriteRow >> (7 - j)) & 1
-			dspPxlOn, err := chip.inout.GetPixel(yCoord, xCoord)
-			if err != nil {
-				log.Fatal(err)
+	xStart := byte(int(chip.V[x]) % chip.width())
+	yStart := byte(int(chip.V[y]) % chip.height())
+	s := chip.scale()
+	wrap := !chip.Quirks.ClipX
+	collision := false
+	addr := chip.I
+	for plane := 0; plane < numPlanes; plane++ {
+		if !chip.planeSelected(plane) {
+			continue
+		}
+		for i := 0; i < n; i++ { // for each row in the sprite
+			yCoord := chip.YCoordFunc(chip, yStart, byte(i))
+			if yCoord >= chip.height() {
+				break
+			}
+			rowAddr := addr + uint16(i*bytesPerRow)
+			spriteRow := uint64(chip.readMem(rowAddr))
+			if bytesPerRow == 2 {
+				spriteRow = spriteRow<<8 | uint64(chip.readMem(rowAddr+1))
+			}
+			pixels := scaleRow(spriteRow, spriteWidth, s)
+			for r := yCoord * s; r < (yCoord+1)*s; r++ { // each chip8 row covers s display rows
+				if chip.fb.XorRow(plane, r, int(xStart)*s, pixels, spriteWidth*s, wrap) {
+					collision = true
+				}
 			}
-			dspPxl := dataconverter.BoolToByte(dspPxlOn)
-			newPxl := dataconverter.ByteToBool(spritePxl ^ dspPxl)
-			err = chip.inout.SetPixel(yCoord, xCoord, newPxl)
-			chip.V[0xF] |= dspPxl & spritePxl
 		}
+		// with both planes selected, the sprite data for the second plane directly follows the first
+		addr += uint16(n * bytesPerRow)
+	}
+	chip.V[0xF] = 0
+	if collision {
+		chip.V[0xF] = 1
 	}
-	chip.inout.Refresh()
+	chip.vblankWait = chip.Quirks.DisplayWait
+	chip.dirty = true
+	if len(chip.hooks.draw) > 0 {
+		chip.notifyDraw(DrawEvent{
+			X:         xStart,
+			Y:         yStart,
+			Width:     spriteWidth,
+			Height:    n,
+			Addr:      chip.I,
+			Planes:    chip.planes,
+			Collision: chip.V[0xF] != 0,
+		})
+	}
+	return nil
 }
 
 // SKP ... Ex9E: Skips the next instruction if the key with value V[x] is pressed
 func (chip *Chip8) SKP(opcode uint16) {
 	x := getOpcodeNibble(opcode, 1)
-	key, _ := chip.inout.ListenNow()
-	if key == chip.V[x] {
-		chip.PC += 2
+	if chip.keyDown(chip.V[x]) {
+		chip.skip()
 	}
 }
 
 // SKNP ... ExA1: Skips the next instruction if the key with the value V[x] is not pressed
 func (chip *Chip8) SKNP(opcode uint16) {
 	x := getOpcodeNibble(opcode, 1)
-	key, _ := chip.inout.ListenNow()
-	if key != chip.V[x] {
-		chip.PC += 2
+	if !chip.keyDown(chip.V[x]) {
+		chip.skip()
+	}
+}
+
+// LDf000 ...F000 nnnn: (XO-CHIP) Loads the 16-bit address stored in the word following the instruction into the index register.
+func (chip *Chip8) LDf000() error {
+	if err := chip.checkMem(chip.PC, 2); err != nil {
+		return err
+	}
+	chip.I = uint16(chip.MEM[chip.PC])<<8 | uint16(chip.MEM[chip.PC+1])
+	chip.PC += 2
+	return nil
+}
+
+// PLANE ...Fn01: (XO-CHIP) Selects the bitplanes that drawing, clearing and scrolling act on using the bitmask n.
+func (chip *Chip8) PLANE(opcode uint16) {
+	chip.planes = getOpcodeNibble(opcode, 1) & 0x3
+}
+
+// AUDIO ...F002: (XO-CHIP) Loads the 16 byte audio pattern buffer from memory starting at I.
+func (chip *Chip8) AUDIO() error {
+	if err := chip.checkMem(chip.I, len(chip.AudioPattern)); err != nil {
+		return err
+	}
+	for i := range chip.AudioPattern {
+		chip.AudioPattern[i] = chip.readMem(chip.I + uint16(i))
+	}
+	chip.notifyPattern()
+	return nil
+}
+
+// LDf07 ...Fx07: Loads the current value of the delay timer into V[x].
+func (chip *Chip8) LDf07(opcode uint16) {
+	x := getOpcodeNibble(opcode, 1)
+	chip.V[x] = chip.DT
+}
+
+// LDf0A ...Fx0A: Waits for a key press and stores its value in V[x]. The instruction repeats until a valid key is received so the timers keep running.
+func (chip *Chip8) LDf0A(opcode uint16) {
+	x := getOpcodeNibble(opcode, 1)
+	// without a keypad no key ever comes, so the wait never ends
+	var key byte = 0xFF
+	var err error
+	if chip.keypad != nil {
+		key, err = chip.keypad.ListenWait()
+	}
+	if err != nil || key > 0xF {
+		chip.notifyKeyWait(KeyWaitEvent{X: x, Waiting: true})
+		chip.PC -= 2
+		return
 	}
+	chip.notifyKeyWait(KeyWaitEvent{X: x, Key: key})
+	chip.V[x] = key
 }
 
-// LDf ...
+// LDf15 ...Fx15: Sets the delay timer to the value at V[x].
+func (chip *Chip8) LDf15(opcode uint16) {
+	x := getOpcodeNibble(opcode, 1)
+	chip.DT = chip.V[x]
+}
+
+// LDf18 ...Fx18: Sets the sound timer to the value at V[x].
+func (chip *Chip8) LDf18(opcode uint16) {
+	x := getOpcodeNibble(opcode, 1)
+	chip.ST = chip.V[x]
+}
+
+// ADDf1E ...Fx1E: Adds the value at V[x] to the index register.
+func (chip *Chip8) ADDf1E(opcode uint16) {
+	x := getOpcodeNibble(opcode, 1)
+	chip.I += uint16(chip.V[x])
+}
+
+// LDf29 ...Fx29: Points the index register at the font sprite for the hex digit stored in the low nibble of V[x].
+func (chip *Chip8) LDf29(opcode uint16) {
+	x := getOpcodeNibble(opcode, 1)
+	chip.I = fontAddress + uint16(chip.V[x]&0xF)*fontCharHeight
+}
+
+// LDf30 ...Fx30: (SUPER-CHIP) Points the index register at the 8x10 big font sprite for the hex digit stored in the low nibble of V[x].
+func (chip *Chip8) LDf30(opcode uint16) {
+	x := getOpcodeNibble(opcode, 1)
+	chip.I = bigFontAddress + uint16(chip.V[x]&0xF)*bigFontCharHeight
+}
+
+// LDf33 ...Fx33: Stores the binary-coded decimal representation of V[x] at I (hundreds), I+1 (tens) and I+2 (ones).
+func (chip *Chip8) LDf33(opcode uint16) error {
+	if err := chip.checkMem(chip.I, 3); err != nil {
+		return err
+	}
+	x := getOpcodeNibble(opcode, 1)
+	val := chip.V[x]
+	chip.writeMem(chip.I, val/100)
+	chip.writeMem(chip.I+1, (val/10)%10)
+	chip.writeMem(chip.I+2, val%10)
+	return nil
+}
+
+// PITCH ...Fx3A: (XO-CHIP) Sets the playback pitch of the audio pattern buffer to the value at V[x].
+func (chip *Chip8) PITCH(opcode uint16) {
+	x := getOpcodeNibble(opcode, 1)
+	chip.Pitch = chip.V[x]
+	chip.notifyPattern()
+}
+
+// LDf55 ...Fx55: Stores V[0] through V[x] in memory starting at I. If the MemoryIncrement quirk is enabled, I is incremented past the stored registers.
+func (chip *Chip8) LDf55(opcode uint16) error {
+	return chip.StoreRegsFunc(chip, opcode)
+}
+
+// LDf65 ...Fx65: Loads V[0] through V[x] from memory starting at I. If the MemoryIncrement quirk is enabled, I is incremented past the loaded registers.
+func (chip *Chip8) LDf65(opcode uint16) error {
+	return chip.LoadRegsFunc(chip, opcode)
+}
+
+// LDf75 ...Fx75: (SUPER-CHIP) Saves V[0] through V[x] to the RPL user flags.
+func (chip *Chip8) LDf75(opcode uint16) {
+	x := getOpcodeNibble(opcode, 1)
+	copy(chip.RPL[:x+1], chip.V[:x+1])
+}
+
+// LDf85 ...Fx85: (SUPER-CHIP) Restores V[0] through V[x] from the RPL user flags.
+func (chip *Chip8) LDf85(opcode uint16) {
+	x := getOpcodeNibble(opcode, 1)
+	copy(chip.V[:x+1], chip.RPL[:x+1])
+}
 
 //#endregion
 
-// MainLoop ... The main switch statement for the Chip8 interpreter. Fetches the opcode that PC is currently pointing to, parses it, and executes it
-func (chip *Chip8) MainLoop() {
+// RunFrame ... Emulates a single 60Hz frame: executes up to instructionsPerFrame instructions and then decrements the timers once.
+// The frame ends early if the program exits, or if it draws while the DisplayWait quirk is enabled.
+// If CyclesPerFrame is set, instructionsPerFrame is ignored and the frame runs under the COSMAC VIP timing model instead.
+// Failed instructions are handled according to the chip's ErrorPolicy. If it halts, the error is returned and the timers are left untouched.
+// Once the frame is complete, the display is presented to the backend if anything was drawn to it
+func (chip *Chip8) RunFrame(instructionsPerFrame int) error {
+	for _, framer := range chip.framers {
+		framer.BeginFrame(chip.Frame)
+	}
+	if chip.CyclesPerFrame > 0 {
+		if err := chip.runCycles(); err != nil {
+			return err
+		}
+		instructionsPerFrame = 0
+	}
+	for i := 0; i < instructionsPerFrame && !chip.exited; i++ {
+		if err := chip.Step(); err != nil {
+			if err = chip.handleError(err); err != nil {
+				return err
+			}
+		}
+		if chip.vblankWait {
+			chip.vblankWait = false
+			break
+		}
+	}
+	chip.tickTimers()
+	chip.Frame++
+	if err := chip.present(); err != nil {
+		return fmt.Errorf("error in chip8/Chip8.RunFrame(): %w", err)
+	}
+	return nil
+}
+
+// handleError ... Applies the chip's ErrorPolicy to an error returned by Step. returns the error if execution should halt
+func (chip *Chip8) handleError(err error) error {
+	switch chip.ErrorPolicy {
+	case Skip:
+		chip.skip()
+		return nil
+	case Trap:
+		var execErr *ExecError
+		if chip.TrapFunc != nil && errors.As(err, &execErr) {
+			return chip.TrapFunc(chip, execErr)
+		}
+	}
+	return err
+}
+
+// Step ... The main switch statement for the Chip8 interpreter. Fetches the opcode that PC is currently pointing to, parses it, and executes it.
+// Step never touches the timers, see RunFrame. If the instruction fails, PC is left pointing at it and an *ExecError is returned
+func (chip *Chip8) Step() error {
 	// Fetch current OpCode
-	opcode := uint16(chip.MEM[chip.PC])<<8 | uint16(chip.MEM[chip.PC+1])
+	pc := chip.PC
+	if int(pc)+1 >= chip.memSize() {
+		return &ExecError{Err: ErrPCOutOfRange, PC: pc}
+	}
+	opcode := uint16(chip.MEM[pc])<<8 | uint16(chip.MEM[pc+1])
 	chip.PC += 2
-	firstNibble := getOpcodeNibble(opcode, 0)
+	chip.Instructions++
 
-	select {
-	case <-chip.ticker.C:
-		if chip.DT > 0 {
-			chip.DT--
+	observed := len(chip.hooks.before) > 0 || len(chip.hooks.after) > 0
+	var inst Instruction
+	if observed {
+		inst = Decode(pc, opcode)
+		for _, h := range chip.hooks.before {
+			h.fn(chip, inst)
 		}
-		if chip.ST > 0 {
-			chip.ST--
-			// chip.inout.Beep()
+	}
+
+	var execErr error
+	if err := chip.execute(opcode); err != nil {
+		chip.PC = pc
+		execErr = &ExecError{Err: err, PC: pc, Opcode: opcode}
+	}
+	chip.notifySound()
+
+	if observed {
+		for _, h := range chip.hooks.after {
+			h.fn(chip, inst, execErr)
 		}
 	}
+	return execErr
+}
+
+// execute ... Decodes and executes a single opcode. returns ErrInvalidOpcode if the opcode isnt part of the chip's instruction set
+func (chip *Chip8) execute(opcode uint16) error {
+	firstNibble := getOpcodeNibble(opcode, 0)
+	super := chip.Quirks.SuperChip
+	xo := chip.Quirks.XOChip
 
 	// Decode and Execute
 	switch firstNibble {
 	case 0x0:
 		lastByte := getOpcodeByte(opcode, 1)
-		switch lastByte {
-		case 0xE0:
+		switch {
+		case lastByte&0xF0 == 0xC0 && super:
+			chip.SCD(opcode) // 00Cn - SCD(nibble)
+		case lastByte == 0xE0:
 			chip.CLS() // 00E0 - CLS
-		case 0xEE:
-			chip.RET() // 00EE - RET
+		case lastByte == 0xEE:
+			return chip.RET() // 00EE - RET
+		case lastByte == 0xFB && super:
+			chip.SCR() // 00FB - SCR
+		case lastByte == 0xFC && super:
+			chip.SCL() // 00FC - SCL
+		case lastByte == 0xFD && super:
+			chip.EXIT() // 00FD - EXIT
+		case lastByte == 0xFE && super:
+			chip.LOW() // 00FE - LOW
+		case lastByte == 0xFF && super:
+			chip.HIGH() // 00FF - HIGH
+		default:
+			// 0nnn - SYS(addr): calls to machine code routines are ignored
 		}
 	case 0x1:
 		chip.JP(opcode) // 1nnn - JP(addr)
 	case 0x2:
-		chip.CALL(opcode) // 2nnn - CALL(addr)
+		return chip.CALL(opcode) // 2nnn - CALL(addr)
 	case 0x3:
 		chip.SE3(opcode) // 3xkk - SE(Vx, byte)
 	case 0x4:
 		chip.SNE4(opcode) // 4xkk - SNE(Vx, byte)
 	case 0x5:
-		chip.SE5(opcode) // 5xy0 - SE(Vx, Vy)
+		lastNibble := getOpcodeNibble(opcode, 3)
+		switch {
+		case lastNibble == 0x0:
+			chip.SE5(opcode) // 5xy0 - SE(Vx, Vy)
+		case lastNibble == 0x2 && xo:
+			return chip.LD52(opcode) // 5xy2 - LD([I], Vx-Vy)
+		case lastNibble == 0x3 && xo:
+			return chip.LD53(opcode) // 5xy3 - LD(Vx-Vy, [I])
+		default:
+			return ErrInvalidOpcode
+		}
 	case 0x6:
 		chip.LD6(opcode) // 6xkk - LD(Vx, byte)
 	case 0x7:
@@ -465,16 +1077,70 @@ func (chip *Chip8) MainLoop() {
 			chip.SUBN(opcode) // 8xy7 - SUBN(Vx, Vy)
 		case 0xE:
 			chip.SHL(opcode) // 8xyE - SHL(Vx Vy)
+		default:
+			return ErrInvalidOpcode
 		}
 	case 0x9:
+		if getOpcodeNibble(opcode, 3) != 0x0 {
+			return ErrInvalidOpcode
+		}
 		chip.SNE9(opcode) // 9xy0 - SE (Vx, Vy)
 	case 0xA:
 		chip.LDa(opcode) // Annn - LD (I, addr) (Load the value nnn into register I)
-	case 0xB: // SuperChipCompatible: Bxnn - JP(Vx, addr)
-		chip.JPb(opcode) // Cosmac Compatible: Bnnn - JP (V0, addr)
+	case 0xB: // JumpVX: Bxnn - JP(Vx, addr)
+		chip.JPb(opcode) // Otherwise: Bnnn - JP (V0, addr)
 	case 0xC:
 		chip.RND(opcode) // Cxnn - RND(Vx, byte)
 	case 0xD: // Dxyn - DRW (Vx, Vy, nibble)
-		chip.DRW(opcode)
+		return chip.DRW(opcode)
+	case 0xE:
+		lastByte := getOpcodeByte(opcode, 1)
+		switch lastByte {
+		case 0x9E:
+			chip.SKP(opcode) // Ex9E - SKP(Vx)
+		case 0xA1:
+			chip.SKNP(opcode) // ExA1 - SKNP(Vx)
+		default:
+			return ErrInvalidOpcode
+		}
+	case 0xF:
+		lastByte := getOpcodeByte(opcode, 1)
+		switch {
+		case opcode == 0xF000 && xo:
+			return chip.LDf000() // F000 nnnn - LD(I, long addr)
+		case lastByte == 0x01 && xo:
+			chip.PLANE(opcode) // Fn01 - PLANE(n)
+		case opcode == 0xF002 && xo:
+			return chip.AUDIO() // F002 - AUDIO
+		case lastByte == 0x07:
+			chip.LDf07(opcode) // Fx07 - LD(Vx, DT)
+		case lastByte == 0x0A:
+			chip.LDf0A(opcode) // Fx0A - LD(Vx, K)
+		case lastByte == 0x15:
+			chip.LDf15(opcode) // Fx15 - LD(DT, Vx)
+		case lastByte == 0x18:
+			chip.LDf18(opcode) // Fx18 - LD(ST, Vx)
+		case lastByte == 0x1E:
+			chip.ADDf1E(opcode) // Fx1E - ADD(I, Vx)
+		case lastByte == 0x29:
+			chip.LDf29(opcode) // Fx29 - LD(F, Vx)
+		case lastByte == 0x30 && super:
+			chip.LDf30(opcode) // Fx30 - LD(HF, Vx)
+		case lastByte == 0x33:
+			return chip.LDf33(opcode) // Fx33 - LD(B, Vx)
+		case lastByte == 0x3A && xo:
+			chip.PITCH(opcode) // Fx3A - PITCH(Vx)
+		case lastByte == 0x55:
+			return chip.LDf55(opcode) // Fx55 - LD([I], Vx)
+		case lastByte == 0x65:
+			return chip.LDf65(opcode) // Fx65 - LD(Vx, [I])
+		case lastByte == 0x75 && super:
+			chip.LDf75(opcode) // Fx75 - LD(R, Vx)
+		case lastByte == 0x85 && super:
+			chip.LDf85(opcode) // Fx85 - LD(Vx, R)
+		default:
+			return ErrInvalidOpcode
+		}
 	}
+	return nil
 }
@@ -0,0 +1,214 @@
+package chip8
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/TH3-F001/GoChip-8/chip8/internal/config"
+	"github.com/TH3-F001/GoChip-8/chip8/internal/framebuffer"
+)
+
+// soundLog ... An io.Audio that records every call to Sound
+type soundLog []bool
+
+func (s *soundLog) Sound(on bool) { *s = append(*s, on) }
+
+// presentCount ... An io.Display that counts the frames presented to it
+type presentCount int
+
+func (p *presentCount) Present(fb *framebuffer.Framebuffer) error {
+	*p++
+	return nil
+}
+
+// newTestChip ... Creates a 64x32 chip running program, with a seeded random source and no keypad
+func newTestChip(t *testing.T, quirks config.Quirks, program []byte, display *presentCount, audio *soundLog) *Chip8 {
+	t.Helper()
+	chip, err := New(quirks, display, nil, audio, NewPCGRandom(1), program, nil, nil, 32, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return chip
+}
+
+func TestStep(t *testing.T) {
+	tests := []struct {
+		name    string
+		quirks  config.Quirks
+		program []byte
+		setup   func(chip *Chip8)
+		wantPC  uint16
+		wantV   map[byte]byte
+		wantI   uint16
+		wantSP  uint16
+		wantMem map[uint16]byte
+		wantErr error
+	}{
+		{name: "6xnn loads a register", program: []byte{0x6A, 0x42}, wantPC: 0x202, wantV: map[byte]byte{0xA: 0x42}},
+		{name: "7xnn wraps without touching VF", program: []byte{0x71, 0x02}, setup: func(c *Chip8) { c.V[1] = 0xFF },
+			wantPC: 0x202, wantV: map[byte]byte{0x1: 0x01, 0xF: 0}},
+		{name: "8xy4 sets the carry", program: []byte{0x80, 0x14}, setup: func(c *Chip8) { c.V[0], c.V[1] = 0xF0, 0x20 },
+			wantPC: 0x202, wantV: map[byte]byte{0x0: 0x10, 0xF: 1}},
+		{name: "8xy5 clears VF on a borrow", program: []byte{0x80, 0x15}, setup: func(c *Chip8) { c.V[0], c.V[1] = 5, 10 },
+			wantPC: 0x202, wantV: map[byte]byte{0x0: 0xFB, 0xF: 0}},
+		{name: "3xnn skips when equal", program: []byte{0x30, 0x05}, setup: func(c *Chip8) { c.V[0] = 5 }, wantPC: 0x204},
+		{name: "3xnn doesnt skip when not equal", program: []byte{0x30, 0x06}, setup: func(c *Chip8) { c.V[0] = 5 }, wantPC: 0x202},
+		{name: "Annn loads I", program: []byte{0xA1, 0x23}, wantPC: 0x202, wantI: 0x123},
+		{name: "2nnn pushes the return address", program: []byte{0x23, 0x00}, wantPC: 0x300, wantSP: 1, wantMem: map[uint16]byte{}},
+		{name: "Fx1E adds to I", program: []byte{0xF0, 0x1E}, setup: func(c *Chip8) { c.I, c.V[0] = 0x300, 0x10 }, wantPC: 0x202, wantI: 0x310},
+		{name: "Fx33 stores BCD", program: []byte{0xF0, 0x33}, setup: func(c *Chip8) { c.I, c.V[0] = 0x300, 123 },
+			wantPC: 0x202, wantI: 0x300, wantMem: map[uint16]byte{0x300: 1, 0x301: 2, 0x302: 3}},
+		{name: "Fx55 increments I with MemoryIncrement", quirks: config.Quirks{MemoryIncrement: true}, program: []byte{0xF1, 0x55},
+			setup: func(c *Chip8) { c.I, c.V[0], c.V[1] = 0x300, 7, 9 }, wantPC: 0x202, wantI: 0x302, wantMem: map[uint16]byte{0x300: 7, 0x301: 9}},
+		{name: "Fx55 leaves I without MemoryIncrement", program: []byte{0xF1, 0x55},
+			setup: func(c *Chip8) { c.I, c.V[0], c.V[1] = 0x300, 7, 9 }, wantPC: 0x202, wantI: 0x300, wantMem: map[uint16]byte{0x300: 7, 0x301: 9}},
+		{name: "00EE underflows an empty stack", program: []byte{0x00, 0xEE}, wantPC: 0x200, wantErr: ErrStackUnderflow},
+		{name: "5xy2 is invalid outside XO-CHIP", program: []byte{0x50, 0x12}, wantPC: 0x200, wantErr: ErrInvalidOpcode},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chip := newTestChip(t, tt.quirks, tt.program, new(presentCount), new(soundLog))
+			if tt.setup != nil {
+				tt.setup(chip)
+			}
+			err := chip.Step()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Step() error = %v, want %v", err, tt.wantErr)
+			}
+			var execErr *ExecError
+			if err != nil && (!errors.As(err, &execErr) || execErr.PC != 0x200) {
+				t.Errorf("Step() error = %#v, want an *ExecError at 0x200", err)
+			}
+			if chip.PC != tt.wantPC {
+				t.Errorf("PC = %#04x, want %#04x", chip.PC, tt.wantPC)
+			}
+			for x, want := range tt.wantV {
+				if chip.V[x] != want {
+					t.Errorf("V[%X] = %#02x, want %#02x", x, chip.V[x], want)
+				}
+			}
+			if chip.I != tt.wantI {
+				t.Errorf("I = %#04x, want %#04x", chip.I, tt.wantI)
+			}
+			if chip.SP != tt.wantSP {
+				t.Errorf("SP = %d, want %d", chip.SP, tt.wantSP)
+			}
+			for addr, want := range tt.wantMem {
+				if chip.MEM[addr] != want {
+					t.Errorf("MEM[%#04x] = %d, want %d", addr, chip.MEM[addr], want)
+				}
+			}
+			if chip.Instructions != 1 {
+				t.Errorf("Instructions = %d, want 1", chip.Instructions)
+			}
+		})
+	}
+}
+
+func TestStepDoesntTickTimers(t *testing.T) {
+	// F015: DT = V0, then 1202 loops forever
+	chip := newTestChip(t, config.Quirks{}, []byte{0xF0, 0x15, 0x12, 0x02}, new(presentCount), new(soundLog))
+	chip.V[0] = 30
+	for i := 0; i < 1000; i++ {
+		if err := chip.Step(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if chip.DT != 30 || chip.Frame != 0 {
+		t.Errorf("after 1000 steps DT = %d, Frame = %d, want 30 and 0", chip.DT, chip.Frame)
+	}
+}
+
+func TestRunFrameTimers(t *testing.T) {
+	// 1200 loops forever, so only the timers change between frames
+	loop := []byte{0x12, 0x00}
+	// 600A F018 sets ST to 10 on the first frame, then 1204 loops forever
+	beep := []byte{0x60, 0x0A, 0xF0, 0x18, 0x12, 0x04}
+	tests := []struct {
+		name      string
+		program   []byte
+		dt, st    byte
+		ipf       int
+		frames    int
+		wantDT    byte
+		wantST    byte
+		wantSound []bool
+	}{
+		{name: "timers start at zero and stay silent", program: loop, ipf: 10, frames: 5},
+		{name: "DT ticks once per frame", program: loop, dt: 5, ipf: 10, frames: 3, wantDT: 2},
+		{name: "DT ticks once per frame whatever the instruction count", program: loop, dt: 5, ipf: 1000, frames: 3, wantDT: 2},
+		{name: "DT stops at zero", program: loop, dt: 2, ipf: 10, frames: 5, wantDT: 0},
+		{name: "ST set during a frame ticks at its end", program: beep, ipf: 10, frames: 1, wantST: 9, wantSound: []bool{true}},
+		{name: "sound stops when ST reaches zero", program: beep, ipf: 10, frames: 10, wantST: 0, wantSound: []bool{true, false}},
+		{name: "sound keeps going until ST reaches zero", program: beep, ipf: 10, frames: 9, wantST: 1, wantSound: []bool{true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sound soundLog
+			chip := newTestChip(t, config.Quirks{}, tt.program, new(presentCount), &sound)
+			// cases that leave dt and st at 0 check the timers New starts with
+			if tt.dt != 0 || tt.st != 0 {
+				chip.DT, chip.ST = tt.dt, tt.st
+			}
+			for i := 0; i < tt.frames; i++ {
+				if err := chip.RunFrame(tt.ipf); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if chip.Frame != uint64(tt.frames) {
+				t.Errorf("Frame = %d, want %d", chip.Frame, tt.frames)
+			}
+			if chip.DT != tt.wantDT || chip.ST != tt.wantST {
+				t.Errorf("DT, ST = %d, %d, want %d, %d", chip.DT, chip.ST, tt.wantDT, tt.wantST)
+			}
+			if len(sound) != len(tt.wantSound) {
+				t.Fatalf("Sound() calls = %v, want %v", sound, tt.wantSound)
+			}
+			for i := range sound {
+				if sound[i] != tt.wantSound[i] {
+					t.Fatalf("Sound() calls = %v, want %v", sound, tt.wantSound)
+				}
+			}
+		})
+	}
+}
+
+func TestDisplayWait(t *testing.T) {
+	// D015 draws, 7001 counts the instructions run after it, and 1200 loops back to draw again
+	program := []byte{0xD0, 0x15, 0x70, 0x01, 0x12, 0x00}
+	tests := []struct {
+		name             string
+		displayWait      bool
+		cyclesPerFrame   int
+		ipf              int
+		wantInstructions uint64
+		wantV0           byte
+	}{
+		{name: "without DisplayWait the frame runs every instruction", ipf: 9, wantInstructions: 9, wantV0: 3},
+		{name: "DisplayWait ends the frame at the draw", displayWait: true, ipf: 9, wantInstructions: 1, wantV0: 0},
+		{name: "VIP timing always ends the frame at the draw", cyclesPerFrame: VIPCyclesPerFrame, wantInstructions: 1, wantV0: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var presented presentCount
+			chip := newTestChip(t, config.Quirks{DisplayWait: tt.displayWait}, program, &presented, new(soundLog))
+			chip.CyclesPerFrame = tt.cyclesPerFrame
+			if err := chip.RunFrame(tt.ipf); err != nil {
+				t.Fatal(err)
+			}
+			if chip.Instructions != tt.wantInstructions || chip.V[0] != tt.wantV0 {
+				t.Errorf("first frame ran %d instructions with V0 = %d, want %d and %d", chip.Instructions, chip.V[0], tt.wantInstructions, tt.wantV0)
+			}
+			if presented != 1 {
+				t.Errorf("presented %d frames, want 1", presented)
+			}
+			// the wait only lasts until the next frame, which picks up after the draw
+			if err := chip.RunFrame(tt.ipf); err != nil {
+				t.Fatal(err)
+			}
+			if tt.displayWait && (chip.Instructions != 4 || chip.V[0] != 1) {
+				t.Errorf("second frame ended after %d instructions with V0 = %d, want 4 and 1", chip.Instructions, chip.V[0])
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package chip8
+
+import (
+	"errors"
+	"fmt"
+)
+
+// #region Errors
+var (
+	// ErrStackOverflow ... Returned when 2nnn (CALL) is executed with a full stack
+	ErrStackOverflow = errors.New("stack overflow")
+	// ErrStackUnderflow ... Returned when 00EE (RET) is executed with an empty stack
+	ErrStackUnderflow = errors.New("stack underflow")
+	// ErrInvalidOpcode ... Returned when the fetched opcode isnt a valid instruction for the chip's Quirks profile
+	ErrInvalidOpcode = errors.New("invalid opcode")
+	// ErrPCOutOfRange ... Returned when the program counter points past the end of addressable memory
+	ErrPCOutOfRange = errors.New("program counter out of range")
+	// ErrMemoryOutOfBounds ... Returned when an instruction reads or writes past the end of addressable memory
+	ErrMemoryOutOfBounds = errors.New("memory access out of bounds")
+)
+
+// ExecError ... The error returned by Step and RunFrame. Wraps one of the Err* values with the address and opcode of the failed instruction,
+// so callers can use errors.Is to find out what went wrong
+type ExecError struct {
+	Err    error
+	PC     uint16
+	Opcode uint16
+}
+
+// Error ... Returns a description of the error along with where it happened
+func (e *ExecError) Error() string {
+	return fmt.Sprintf("%v at PC=%#04x (opcode %#04x)", e.Err, e.PC, e.Opcode)
+}
+
+// Unwrap ... Returns the underlying Err* value
+func (e *ExecError) Unwrap() error {
+	return e.Err
+}
+
+//#endregion
+
+// #region Error Policies
+
+// ErrorPolicy ... Decides what RunFrame does when an instruction returns an error
+type ErrorPolicy int
+
+const (
+	// Halt ... RunFrame stops and returns the error, leaving PC on the failed instruction
+	Halt ErrorPolicy = iota
+	// Skip ... RunFrame moves PC past the failed instruction and carries on
+	Skip
+	// Trap ... RunFrame hands the error to the chip's TrapFunc. If TrapFunc returns an error, RunFrame halts with it, otherwise it carries on from wherever TrapFunc left PC
+	Trap
+)
+
+// ParseErrorPolicy ... Returns the ErrorPolicy with the given name ("halt", "skip" or "trap"). An empty name is treated as "halt"
+func ParseErrorPolicy(name string) (ErrorPolicy, error) {
+	switch name {
+	case "halt", "":
+		return Halt, nil
+	case "skip":
+		return Skip, nil
+	case "trap":
+		return Trap, nil
+	}
+	return Halt, fmt.Errorf("error in chip8/ParseErrorPolicy(): unknown error policy %q. expected halt, skip or trap", name)
+}
+
+//#endregion
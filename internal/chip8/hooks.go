@@ -0,0 +1,223 @@
+package chip8
+
+// HookID ... Identifies a registered hook so that it can be removed with RemoveHook
+type HookID int
+
+// Instruction ... An instruction along with each of its possible operands. Which operands are meaningful depends on the opcode
+type Instruction struct {
+	PC     uint16
+	Opcode uint16
+	X      byte
+	Y      byte
+	N      byte
+	NN     byte
+	NNN    uint16
+}
+
+// Decode ... Splits the opcode at pc into its operands
+func Decode(pc, opcode uint16) Instruction {
+	return Instruction{
+		PC:     pc,
+		Opcode: opcode,
+		X:      getOpcodeNibble(opcode, 1),
+		Y:      getOpcodeNibble(opcode, 2),
+		N:      getOpcodeNibble(opcode, 3),
+		NN:     getOpcodeByte(opcode, 1),
+		NNN:    opcode & 0x0FFF,
+	}
+}
+
+// MemoryAccess ... A read or write of a single byte of memory by an instruction. Value is the byte read, or the byte written
+type MemoryAccess struct {
+	Addr  uint16
+	Value byte
+	Write bool
+}
+
+// DrawEvent ... A sprite drawn by Dxyn. X and Y are the sprite's top left corner, Addr is where its data was read from,
+// and Collision is true if it turned off any lit pixels
+type DrawEvent struct {
+	X         byte
+	Y         byte
+	Width     int
+	Height    int
+	Addr      uint16
+	Planes    byte
+	Collision bool
+}
+
+// KeyWaitEvent ... Sent by Fx0A when it starts waiting for a key (Waiting is true), and again once a key arrives (Waiting is false, Key is the key)
+type KeyWaitEvent struct {
+	X       byte
+	Key     byte
+	Waiting bool
+}
+
+// hook ... A registered callback along with the id it was registered under
+type hook[F any] struct {
+	id HookID
+	fn F
+}
+
+// memoryWatch ... A memory watch callback along with the inclusive address range it watches
+type memoryWatch struct {
+	start uint16
+	end   uint16
+	fn    func(*Chip8, MemoryAccess)
+}
+
+// hooks ... Every callback registered on a chip. Empty unless something is observing the chip, so the checks cost almost nothing
+// keyWaiting and soundOn remember the last state sent to the key wait and sound hooks, so that they only see changes
+type hooks struct {
+	nextID     HookID
+	before     []hook[func(*Chip8, Instruction)]
+	after      []hook[func(*Chip8, Instruction, error)]
+	memory     []hook[memoryWatch]
+	draw       []hook[func(*Chip8, DrawEvent)]
+	sound      []hook[func(*Chip8, bool)]
+	keyWait    []hook[func(*Chip8, KeyWaitEvent)]
+	keyWaiting bool
+	soundOn    bool
+}
+
+// newID ... Returns an id that hasnt been handed out before
+func (h *hooks) newID() HookID {
+	h.nextID++
+	return h.nextID
+}
+
+// #region Registration
+
+// OnBeforeInstruction ... Registers fn to be called with every instruction just before it is executed
+func (chip *Chip8) OnBeforeInstruction(fn func(*Chip8, Instruction)) HookID {
+	id := chip.hooks.newID()
+	chip.hooks.before = append(chip.hooks.before, hook[func(*Chip8, Instruction)]{id, fn})
+	return id
+}
+
+// OnAfterInstruction ... Registers fn to be called with every instruction just after it is executed, along with the *ExecError it failed with if any
+func (chip *Chip8) OnAfterInstruction(fn func(*Chip8, Instruction, error)) HookID {
+	id := chip.hooks.newID()
+	chip.hooks.after = append(chip.hooks.after, hook[func(*Chip8, Instruction, error)]{id, fn})
+	return id
+}
+
+// WatchMemory ... Registers fn to be called whenever an instruction reads or writes memory between start and end (inclusive).
+// Instruction fetches arent reported, use OnBeforeInstruction for those
+func (chip *Chip8) WatchMemory(start, end uint16, fn func(*Chip8, MemoryAccess)) HookID {
+	id := chip.hooks.newID()
+	chip.hooks.memory = append(chip.hooks.memory, hook[memoryWatch]{id, memoryWatch{start, end, fn}})
+	return id
+}
+
+// OnDraw ... Registers fn to be called after every sprite drawn by Dxyn
+func (chip *Chip8) OnDraw(fn func(*Chip8, DrawEvent)) HookID {
+	id := chip.hooks.newID()
+	chip.hooks.draw = append(chip.hooks.draw, hook[func(*Chip8, DrawEvent)]{id, fn})
+	return id
+}
+
+// OnSound ... Registers fn to be called with true when the sound timer starts the beep, and false when it stops
+func (chip *Chip8) OnSound(fn func(*Chip8, bool)) HookID {
+	id := chip.hooks.newID()
+	chip.hooks.sound = append(chip.hooks.sound, hook[func(*Chip8, bool)]{id, fn})
+	return id
+}
+
+// OnKeyWait ... Registers fn to be called when Fx0A starts waiting for a key, and when the key arrives
+func (chip *Chip8) OnKeyWait(fn func(*Chip8, KeyWaitEvent)) HookID {
+	id := chip.hooks.newID()
+	chip.hooks.keyWait = append(chip.hooks.keyWait, hook[func(*Chip8, KeyWaitEvent)]{id, fn})
+	return id
+}
+
+// RemoveHook ... Unregisters the hook registered under id. Does nothing if there is no such hook
+func (chip *Chip8) RemoveHook(id HookID) {
+	chip.hooks.before = removeHook(chip.hooks.before, id)
+	chip.hooks.after = removeHook(chip.hooks.after, id)
+	chip.hooks.memory = removeHook(chip.hooks.memory, id)
+	chip.hooks.draw = removeHook(chip.hooks.draw, id)
+	chip.hooks.sound = removeHook(chip.hooks.sound, id)
+	chip.hooks.keyWait = removeHook(chip.hooks.keyWait, id)
+}
+
+// removeHook ... Returns hooks without the hook registered under id. hooks are copied rather than modified in place,
+// so that a hook can remove itself while the list is being iterated over
+func removeHook[F any](hooks []hook[F], id HookID) []hook[F] {
+	for i, h := range hooks {
+		if h.id == id {
+			return append(hooks[:i:i], hooks[i+1:]...)
+		}
+	}
+	return hooks
+}
+
+//#endregion
+
+// #region Notification
+
+// readMem ... Returns the byte at addr, reporting the read to any memory watches covering it. addr must have been checked with checkMem
+func (chip *Chip8) readMem(addr uint16) byte {
+	value := chip.MEM[addr]
+	if len(chip.hooks.memory) > 0 {
+		chip.notifyMemory(MemoryAccess{Addr: addr, Value: value})
+	}
+	return value
+}
+
+// writeMem ... Stores value at addr, reporting the write to any memory watches covering it. addr must have been checked with checkMem
+func (chip *Chip8) writeMem(addr uint16, value byte) {
+	chip.MEM[addr] = value
+	if len(chip.hooks.memory) > 0 {
+		chip.notifyMemory(MemoryAccess{Addr: addr, Value: value, Write: true})
+	}
+}
+
+func (chip *Chip8) notifyMemory(access MemoryAccess) {
+	for _, h := range chip.hooks.memory {
+		if access.Addr >= h.fn.start && access.Addr <= h.fn.end {
+			h.fn.fn(chip, access)
+		}
+	}
+}
+
+func (chip *Chip8) notifyDraw(event DrawEvent) {
+	for _, h := range chip.hooks.draw {
+		h.fn(chip, event)
+	}
+}
+
+// notifyKeyWait ... Reports the start or end of a key wait, ignoring repeats of Fx0A while it is still waiting
+func (chip *Chip8) notifyKeyWait(event KeyWaitEvent) {
+	if event.Waiting == chip.hooks.keyWaiting {
+		return
+	}
+	chip.hooks.keyWaiting = event.Waiting
+	for _, h := range chip.hooks.keyWait {
+		h.fn(chip, event)
+	}
+}
+
+// notifySound ... Starts or stops the audio, and reports the sound starting or stopping, if the sound timer has crossed zero since the last call
+func (chip *Chip8) notifySound() {
+	on := chip.ST > 0
+	if on == chip.hooks.soundOn {
+		return
+	}
+	chip.hooks.soundOn = on
+	if chip.audio != nil {
+		chip.audio.Sound(on)
+	}
+	for _, h := range chip.hooks.sound {
+		h.fn(chip, on)
+	}
+}
+
+//#endregion
+
+// notifyPattern ... Hands the XO-CHIP audio pattern and the rate it plays at to the audio, if it can play patterns. Called whenever either changes
+func (chip *Chip8) notifyPattern() {
+	if chip.pattern != nil && chip.Quirks.XOChip {
+		chip.pattern.SetPattern(chip.AudioPattern, chip.AudioSampleRate())
+	}
+}
@@ -0,0 +1,119 @@
+package chip8
+
+import (
+	"encoding"
+	"encoding/binary"
+	"fmt"
+	"math/rand/v2"
+)
+
+// Random ... A source of random bytes for the RND instruction. Its state must be serializable so that it can be saved along with the rest of the machine
+type Random interface {
+	encoding.BinaryMarshaler
+	encoding.BinaryUnmarshaler
+
+	// Byte ... Returns the next random byte
+	Byte() byte
+}
+
+// NewRandom ... Returns the named generator ("pcg" or "page") seeded with seed. An empty name is treated as "pcg"
+func NewRandom(name string, seed uint64) (Random, error) {
+	switch name {
+	case "pcg", "":
+		return NewPCGRandom(seed), nil
+	case "page":
+		return NewPageRandom(seed), nil
+	}
+	return nil, fmt.Errorf("error in chip8/NewRandom(): unknown random generator %q. expected pcg or page", name)
+}
+
+// #region PCGRandom
+
+// PCGRandom ... The default generator. A permuted congruential generator from math/rand/v2
+type PCGRandom struct {
+	pcg *rand.PCG
+}
+
+// NewPCGRandom ... Returns a PCGRandom seeded with seed
+func NewPCGRandom(seed uint64) *PCGRandom {
+	return &PCGRandom{pcg: rand.NewPCG(seed, seed^0x9E3779B97F4A7C15)}
+}
+
+// Byte ... Returns the next random byte
+func (r *PCGRandom) Byte() byte {
+	return byte(r.pcg.Uint64())
+}
+
+// MarshalBinary ... Returns the generator's state
+func (r *PCGRandom) MarshalBinary() ([]byte, error) {
+	return r.pcg.MarshalBinary()
+}
+
+// UnmarshalBinary ... Restores a state returned by MarshalBinary
+func (r *PCGRandom) UnmarshalBinary(data []byte) error {
+	return r.pcg.UnmarshalBinary(data)
+}
+
+//#endregion
+
+// #region PageRandom
+
+// vipInterpreterPage ... The second page (0x100-0x1FF) of the COSMAC VIP's CHIP-8 interpreter. Its RND routine lives in this page and reads it as its source of randomness
+var vipInterpreterPage = [0x100]byte{
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x45, 0xA3, 0x98, 0x56, 0xD4, 0xF8, 0x81, 0xBC, 0xF8, 0x95, 0xAC,
+	0x22, 0xDC, 0x12, 0x56, 0xD4, 0x06, 0xB8, 0xD4, 0x06, 0xA8, 0xD4, 0x64, 0x0A, 0x01, 0xE6, 0x8A,
+	0xF4, 0xAA, 0x3B, 0x28, 0x9A, 0xFC, 0x01, 0xBA, 0xD4, 0xF8, 0x81, 0xBA, 0x06, 0xFA, 0x0F, 0xAA,
+	0x0A, 0xAA, 0xD4, 0xE6, 0x06, 0xBF, 0x93, 0xBE, 0xF8, 0x1B, 0xAE, 0x2A, 0x1A, 0xF8, 0x00, 0x5A,
+	0x0E, 0xF5, 0x3B, 0x4B, 0x56, 0x0A, 0xFC, 0x01, 0x5A, 0x30, 0x40, 0x4E, 0xF6, 0x3B, 0x3C, 0x9F,
+	0x56, 0x2A, 0x2A, 0xD4, 0x00, 0x22, 0x86, 0x52, 0xF8, 0xF0, 0xA7, 0x07, 0x5A, 0x87, 0xF3, 0x17,
+	0x1A, 0x3A, 0x5B, 0x12, 0xD4, 0x22, 0x86, 0x52, 0xF8, 0xF0, 0xA7, 0x0A, 0x57, 0x87, 0xF3, 0x17,
+	0x1A, 0x3A, 0x6B, 0x12, 0xD4, 0x15, 0x85, 0x22, 0x73, 0x95, 0x52, 0x25, 0x45, 0xA5, 0x86, 0xFA,
+	0x0F, 0xB5, 0xD4, 0x45, 0xE6, 0xF3, 0x3A, 0x82, 0x15, 0x15, 0xD4, 0x45, 0xE6, 0xF3, 0x3A, 0x88,
+	0xD4, 0x45, 0x07, 0x30, 0x8C, 0x45, 0x07, 0x30, 0x84, 0xE6, 0x62, 0x26, 0x45, 0xA3, 0x36, 0x88,
+	0xD4, 0x3E, 0x88, 0xD4, 0xF8, 0xF0, 0xA7, 0xE7, 0x45, 0xF4, 0xA5, 0x86, 0xFA, 0x0F, 0x3B, 0xB2,
+	0xFC, 0x01, 0xB5, 0xD4, 0x45, 0x56, 0xD4, 0x45, 0xE6, 0xF4, 0x56, 0xD4, 0x45, 0xFA, 0x0F, 0x3A,
+	0xC4, 0x07, 0x56, 0xD4, 0xAF, 0x22, 0xF8, 0xD3, 0x73, 0x8F, 0xF9, 0xF0, 0x52, 0xE6, 0x07, 0xD2,
+	0x56, 0xF8, 0xFF, 0xA6, 0xF8, 0x00, 0x7E, 0x56, 0xD4, 0x19, 0x89, 0xAE, 0x93, 0xBE, 0x99, 0xEE,
+	0xF4, 0x56, 0x76, 0xE6, 0xF4, 0xB9, 0x56, 0x45, 0xF2, 0x56, 0xD4, 0x45, 0xAA, 0x86, 0xFA, 0x0F,
+	0xBA, 0xD4, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xE0, 0x00, 0x4B,
+}
+
+// PageRandom ... The COSMAC VIP interpreter's own RND. The VIP had no random source, so CXKK steps R9's low byte through the interpreter's
+// code page and folds the byte it finds there into R9's high byte, which becomes the random number.
+// Its sequence is short and far from uniform, but matches the VIP's given the same starting R9
+type PageRandom struct {
+	r9 uint16
+}
+
+// NewPageRandom ... Returns a PageRandom whose R9 starts at the low two bytes of seed. A real VIP starts with whatever R9 held at power on
+func NewPageRandom(seed uint64) *PageRandom {
+	return &PageRandom{r9: uint16(seed)}
+}
+
+// Byte ... Returns the next random byte, by the same steps as the interpreter's routine at 0x1D9
+func (r *PageRandom) Byte() byte {
+	// INC R9, then ADD the interpreter byte R9.0 points at to R9.1, keeping the carry in DF
+	r.r9++
+	sum := uint16(r.r9>>8) + uint16(vipInterpreterPage[byte(r.r9)])
+	d := byte(sum)
+	// SHRC shifts DF back in at the top, and the result is added to the sum it was shifted from
+	d += d>>1 | byte(sum>>8)<<7
+	r.r9 = uint16(d)<<8 | r.r9&0xFF
+	return d
+}
+
+// MarshalBinary ... Returns the generator's state, R9
+func (r *PageRandom) MarshalBinary() ([]byte, error) {
+	return binary.BigEndian.AppendUint16(nil, r.r9), nil
+}
+
+// UnmarshalBinary ... Restores a state returned by MarshalBinary
+func (r *PageRandom) UnmarshalBinary(data []byte) error {
+	if len(data) != 2 {
+		return fmt.Errorf("error in chip8/PageRandom.UnmarshalBinary(): invalid PageRandom state")
+	}
+	r.r9 = binary.BigEndian.Uint16(data)
+	return nil
+}
+
+//#endregion
@@ -0,0 +1,47 @@
+package chip8
+
+import "testing"
+
+func TestPageRandomSequence(t *testing.T) {
+	tests := []struct {
+		name string
+		seed uint64
+		want []byte
+	}{
+		// 0x100-0x104 are zero, then 0x45 at 0x105 gives 0x45 + 0x45>>1, and 0xA3 at 0x106 carries into the shift: 0x0A + 0x85
+		{name: "R9 starting at zero", seed: 0, want: []byte{0x00, 0x00, 0x00, 0x00, 0x67, 0x8F, 0xBA, 0x98, 0x22, 0xA7, 0xBC, 0x34}},
+		// R9's low byte wraps from 0xFF, carrying into the high byte before it is added to
+		{name: "R9 wrapping its low byte", seed: 0x12FE, want: []byte{0x8B, 0xD2, 0x3B, 0x58, 0x84, 0xC6}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewPageRandom(tt.seed)
+			for i, want := range tt.want {
+				if got := r.Byte(); got != want {
+					t.Fatalf("byte %d = %#02x, want %#02x", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestPageRandomState(t *testing.T) {
+	r := NewPageRandom(0x1234)
+	r.Byte()
+	state, err := r.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := NewPageRandom(0)
+	if err := restored.UnmarshalBinary(state); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 300; i++ {
+		if got, want := restored.Byte(), r.Byte(); got != want {
+			t.Fatalf("byte %d after restoring = %#02x, want %#02x", i, got, want)
+		}
+	}
+	if err := restored.UnmarshalBinary([]byte{'v', 'i', 'p', ':', 1, 2}); err == nil {
+		t.Error("UnmarshalBinary() accepted a 6 byte state")
+	}
+}
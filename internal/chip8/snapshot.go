@@ -0,0 +1,220 @@
+package chip8
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/TH3-F001/GoChip-8/chip8/internal/config"
+	"github.com/TH3-F001/GoChip-8/chip8/internal/dataconverter"
+)
+
+// Snapshot layout (all values big endian):
+//
+//	magic   [4]byte  "GC8S"
+//	version uint16   SnapshotVersion
+//	regs    snapshotRegs
+//	mem     uint32 length, followed by that many bytes of MEM
+//	display byte height, byte width, followed by one bit per pixel for each bitplane, packed row by row
+//	rng     uint32 length, followed by the random source's binary state
+const snapshotMagic = "GC8S"
+
+// SnapshotVersion ... The version of the snapshot format written by Snapshot. Restore rejects any other version
+const SnapshotVersion uint16 = 2
+
+// ErrInvalidSnapshot ... Returned by Restore when the data isnt a snapshot, was written by a different version or for a different display size, or holds a register out of range
+var ErrInvalidSnapshot = errors.New("invalid snapshot")
+
+// snapshotRegs ... The fixed size part of a snapshot. Field order is part of the format, so new fields must bump SnapshotVersion
+type snapshotRegs struct {
+	V            [16]byte
+	I            uint16
+	PC           uint16
+	SP           uint16
+	STK          [16]uint16
+	DT           byte
+	ST           byte
+	RPL          [16]byte
+	AudioPattern [16]byte
+	Pitch        byte
+	Frame        uint64
+	Cycles       int32
+	Quirks       uint16
+	Hires        byte
+	Planes       byte
+	Exited       byte
+}
+
+// validate ... Returns an error naming the first register that is out of range, so that a damaged snapshot cant leave the chip indexing past its stack or bitplanes
+func (regs *snapshotRegs) validate() error {
+	switch {
+	case int(regs.SP) > len(regs.STK):
+		return fmt.Errorf("stack pointer %d is past the %d entry stack", regs.SP, len(regs.STK))
+	case regs.Planes >= 1<<numPlanes:
+		return fmt.Errorf("plane mask %#02x selects planes past the %d bitplanes", regs.Planes, numPlanes)
+	case regs.Hires > 1:
+		return fmt.Errorf("hires flag is %d", regs.Hires)
+	case regs.Exited > 1:
+		return fmt.Errorf("exited flag is %d", regs.Exited)
+	}
+	return nil
+}
+
+// quirkBits ... Returns pointers to each of the quirks in the order they are packed into a snapshot
+func quirkBits(q *config.Quirks) []*bool {
+	return []*bool{
+		&q.VFReset,
+		&q.MemoryIncrement,
+		&q.DisplayWait,
+		&q.ClipX,
+		&q.ClipY,
+		&q.ShiftVY,
+		&q.JumpVX,
+		&q.ScrollHalfPixels,
+		&q.SuperChip,
+		&q.XOChip,
+	}
+}
+
+func encodeQuirks(q config.Quirks) uint16 {
+	var bits uint16
+	for i, quirk := range quirkBits(&q) {
+		if *quirk {
+			bits |= 1 << i
+		}
+	}
+	return bits
+}
+
+func decodeQuirks(bits uint16) config.Quirks {
+	var q config.Quirks
+	for i, quirk := range quirkBits(&q) {
+		*quirk = bits&(1<<i) != 0
+	}
+	return q
+}
+
+// Snapshot ... Serializes the entire machine state (memory, registers, stack, timers, display, quirks and random source) into the versioned snapshot format
+func (chip *Chip8) Snapshot() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(snapshotMagic)
+	binary.Write(&buf, binary.BigEndian, SnapshotVersion)
+
+	regs := snapshotRegs{
+		V:            chip.V,
+		I:            chip.I,
+		PC:           chip.PC,
+		SP:           chip.SP,
+		STK:          chip.STK,
+		DT:           chip.DT,
+		ST:           chip.ST,
+		RPL:          chip.RPL,
+		AudioPattern: chip.AudioPattern,
+		Pitch:        chip.Pitch,
+		Frame:        chip.Frame,
+		Cycles:       int32(chip.cycles),
+		Quirks:       encodeQuirks(chip.Quirks),
+		Hires:        dataconverter.BoolToByte(chip.hires),
+		Planes:       chip.planes,
+		Exited:       dataconverter.BoolToByte(chip.exited),
+	}
+	binary.Write(&buf, binary.BigEndian, regs)
+
+	binary.Write(&buf, binary.BigEndian, uint32(len(chip.MEM)))
+	buf.Write(chip.MEM[:])
+
+	buf.WriteByte(chip.dh)
+	buf.WriteByte(chip.dw)
+	buf.Write(chip.fb.Pack())
+
+	rngState, err := chip.random.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("error in chip8/Chip8.Snapshot(): %w", err)
+	}
+	binary.Write(&buf, binary.BigEndian, uint32(len(rngState)))
+	buf.Write(rngState)
+
+	return buf.Bytes(), nil
+}
+
+// Restore ... Replaces the entire machine state with one previously returned by Snapshot, and presents the display.
+// The chip is left untouched if the snapshot cant be read
+func (chip *Chip8) Restore(data []byte) error {
+	r := bytes.NewReader(data)
+	magic := make([]byte, len(snapshotMagic))
+	var version uint16
+	if _, err := io.ReadFull(r, magic); err != nil || string(magic) != snapshotMagic {
+		return fmt.Errorf("error in chip8/Chip8.Restore(): %w: bad magic", ErrInvalidSnapshot)
+	}
+	if err := binary.Read(r, binary.BigEndian, &version); err != nil || version != SnapshotVersion {
+		return fmt.Errorf("error in chip8/Chip8.Restore(): %w: unsupported version %d", ErrInvalidSnapshot, version)
+	}
+
+	var regs snapshotRegs
+	if err := binary.Read(r, binary.BigEndian, &regs); err != nil {
+		return fmt.Errorf("error in chip8/Chip8.Restore(): %w: %v", ErrInvalidSnapshot, err)
+	}
+	if err := regs.validate(); err != nil {
+		return fmt.Errorf("error in chip8/Chip8.Restore(): %w: %v", ErrInvalidSnapshot, err)
+	}
+
+	var memLen uint32
+	if err := binary.Read(r, binary.BigEndian, &memLen); err != nil || int(memLen) != len(chip.MEM) {
+		return fmt.Errorf("error in chip8/Chip8.Restore(): %w: memory size mismatch", ErrInvalidSnapshot)
+	}
+	mem := make([]byte, memLen)
+	if _, err := io.ReadFull(r, mem); err != nil {
+		return fmt.Errorf("error in chip8/Chip8.Restore(): %w: %v", ErrInvalidSnapshot, err)
+	}
+
+	dh, _ := r.ReadByte()
+	dw, err := r.ReadByte()
+	if err != nil || dh != chip.dh || dw != chip.dw {
+		return fmt.Errorf("error in chip8/Chip8.Restore(): %w: snapshot display is %dx%d, expected %dx%d", ErrInvalidSnapshot, dw, dh, chip.dw, chip.dh)
+	}
+	display := make([]byte, chip.fb.PackedLen())
+	if _, err := io.ReadFull(r, display); err != nil {
+		return fmt.Errorf("error in chip8/Chip8.Restore(): %w: %v", ErrInvalidSnapshot, err)
+	}
+
+	var rngLen uint32
+	if err := binary.Read(r, binary.BigEndian, &rngLen); err != nil || int(rngLen) > r.Len() {
+		return fmt.Errorf("error in chip8/Chip8.Restore(): %w: bad random source state", ErrInvalidSnapshot)
+	}
+	rngState := make([]byte, rngLen)
+	if _, err := io.ReadFull(r, rngState); err != nil {
+		return fmt.Errorf("error in chip8/Chip8.Restore(): %w: %v", ErrInvalidSnapshot, err)
+	}
+	if err := chip.random.UnmarshalBinary(rngState); err != nil {
+		return fmt.Errorf("error in chip8/Chip8.Restore(): %w: %v", ErrInvalidSnapshot, err)
+	}
+
+	chip.V = regs.V
+	chip.I = regs.I
+	chip.PC = regs.PC
+	chip.SP = regs.SP
+	chip.STK = regs.STK
+	chip.DT = regs.DT
+	chip.ST = regs.ST
+	chip.RPL = regs.RPL
+	chip.AudioPattern = regs.AudioPattern
+	chip.Pitch = regs.Pitch
+	chip.Frame = regs.Frame
+	chip.cycles = int(regs.Cycles)
+	chip.applyQuirks(decodeQuirks(regs.Quirks))
+	chip.hires = dataconverter.ByteToBool(regs.Hires)
+	chip.planes = regs.Planes
+	chip.exited = dataconverter.ByteToBool(regs.Exited)
+	chip.vblankWait = false
+	copy(chip.MEM[:], mem)
+	chip.fb.Unpack(display)
+	chip.notifySound()
+	chip.notifyPattern()
+	chip.dirty = true
+	if err := chip.present(); err != nil {
+		return fmt.Errorf("error in chip8/Chip8.Restore(): %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package chip8
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/TH3-F001/GoChip-8/chip8/internal/config"
+)
+
+// snapshotProgram ... Draws a sprite, then calls a subroutine that draws a random number into V1 and counts in V0 forever, leaving a return address on the stack
+var snapshotProgram = []byte{
+	0xA2, 0x10, // I = sprite
+	0x60, 0x05, // V0 = 5
+	0xD0, 0x05, // draw the sprite at V0, V0
+	0x22, 0x0A, // call 0x20A
+	0x12, 0x08, // unreachable
+	0xC1, 0xFF, // V1 = random
+	0x70, 0x01, // V0 += 1
+	0x12, 0x0A, // loop back to 0x20A
+	0xF0, 0x90, 0xF0, 0x90, 0x90, // sprite
+}
+
+// runFrames ... Runs the given number of frames of 10 instructions, failing the test on any error
+func runFrames(t *testing.T, chip *Chip8, frames int) {
+	t.Helper()
+	for i := 0; i < frames; i++ {
+		if err := chip.RunFrame(10); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	var presented presentCount
+	chip := newTestChip(t, config.Quirks{}, snapshotProgram, &presented, new(soundLog))
+	runFrames(t, chip, 1)
+	snapshot, err := chip.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantV, wantPC, wantSP, wantFrame, wantDisplay := chip.V, chip.PC, chip.SP, chip.Frame, chip.fb.Pack()
+
+	// the random source is part of the snapshot, so the frames after it must play out the same way twice
+	runFrames(t, chip, 3)
+	wantAfter := chip.V
+	chip.fb.Clear(0)
+
+	if err := chip.Restore(snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if chip.V != wantV || chip.PC != wantPC || chip.SP != wantSP || chip.Frame != wantFrame {
+		t.Errorf("restored V, PC, SP, Frame = %v, %#04x, %d, %d, want %v, %#04x, %d, %d", chip.V, chip.PC, chip.SP, chip.Frame, wantV, wantPC, wantSP, wantFrame)
+	}
+	if !bytes.Equal(chip.fb.Pack(), wantDisplay) {
+		t.Error("restored display differs from the snapshot's")
+	}
+	if again, err := chip.Snapshot(); err != nil || !bytes.Equal(again, snapshot) {
+		t.Errorf("snapshot of the restored chip differs from the one it was restored from (err = %v)", err)
+	}
+	if presented != 2 {
+		t.Errorf("presented %d frames, want 2: the first frame and the restored one", presented)
+	}
+	runFrames(t, chip, 3)
+	if chip.V != wantAfter {
+		t.Errorf("V after replaying = %v, want %v", chip.V, wantAfter)
+	}
+}
+
+func TestRestoreRejectsInvalidSnapshots(t *testing.T) {
+	chip := newTestChip(t, config.Quirks{XOChip: true}, snapshotProgram, new(presentCount), new(soundLog))
+	runFrames(t, chip, 1)
+	snapshot, err := chip.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// withRegs ... Returns a copy of snapshot with its registers changed by edit
+	withRegs := func(edit func(regs *snapshotRegs)) []byte {
+		var regs snapshotRegs
+		header := len(snapshotMagic) + 2
+		if err := binary.Read(bytes.NewReader(snapshot[header:]), binary.BigEndian, &regs); err != nil {
+			t.Fatal(err)
+		}
+		edit(&regs)
+		var buf bytes.Buffer
+		buf.Write(snapshot[:header])
+		binary.Write(&buf, binary.BigEndian, regs)
+		buf.Write(snapshot[header+binary.Size(regs):])
+		return buf.Bytes()
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "bad magic", data: append([]byte("GC8X"), snapshot[4:]...)},
+		{name: "other version", data: append(append([]byte(snapshotMagic), 0, 1), snapshot[6:]...)},
+		{name: "truncated registers", data: snapshot[:20]},
+		{name: "stack pointer past the stack", data: withRegs(func(regs *snapshotRegs) { regs.SP = 17 })},
+		{name: "plane mask past the bitplanes", data: withRegs(func(regs *snapshotRegs) { regs.Planes = 4 })},
+		{name: "hires flag out of range", data: withRegs(func(regs *snapshotRegs) { regs.Hires = 2 })},
+		{name: "exited flag out of range", data: withRegs(func(regs *snapshotRegs) { regs.Exited = 0xFF })},
+		{name: "truncated random source state", data: snapshot[:len(snapshot)-1]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := chip.Restore(tt.data); !errors.Is(err, ErrInvalidSnapshot) {
+				t.Fatalf("Restore() error = %v, want ErrInvalidSnapshot", err)
+			}
+			if after, _ := chip.Snapshot(); !bytes.Equal(after, snapshot) {
+				t.Error("Restore() changed the chip after rejecting the snapshot")
+			}
+		})
+	}
+
+	// a full stack and every plane selected are still in range
+	if err := chip.Restore(withRegs(func(regs *snapshotRegs) { regs.SP, regs.Planes = 16, 3 })); err != nil {
+		t.Errorf("Restore() of a full stack with both planes selected error = %v, want nil", err)
+	}
+}
@@ -0,0 +1,121 @@
+package chip8
+
+// COSMAC VIP timing model
+//
+// The VIP's RCA 1802 runs at 1.7609MHz, and every 1802 instruction takes 2 or 3 machine cycles of 8 clock cycles each.
+// The display hardware steals 128 lines of 8 DMA cycles each frame, plus the cycles of the interrupt routine that sets the DMA up
+// and decrements the timers, leaving the interpreter with the rest.
+// The costs below are the number of machine cycles the VIP interpreter spends executing each instruction, on top of vipFetchCycles
+// for fetching and decoding it. They are approximate: instructions whose duration depends on their operands are charged using the same formula for every operand
+const (
+	// VIPCyclesPerFrame ... The machine cycles available to the VIP interpreter in every 60Hz frame, once the display DMA and interrupt have had theirs
+	VIPCyclesPerFrame = 3668 - vipDisplayCycles
+	// vipDisplayCycles ... The machine cycles taken every frame by the display DMA (128 lines of 8 bytes) and the display interrupt routine
+	vipDisplayCycles = 128*8 + 46
+	// vipFetchCycles ... The machine cycles the interpreter spends fetching an instruction and jumping to its handler
+	vipFetchCycles = 40
+	// vipSkipCycles ... The extra machine cycles taken when a skip instruction skips
+	vipSkipCycles = 4
+)
+
+// vipCycles ... Returns the machine cycles the COSMAC VIP takes to execute opcode with the chip's current registers, excluding any vblank wait
+func (chip *Chip8) vipCycles(opcode uint16) int {
+	x := getOpcodeNibble(opcode, 1)
+	n := int(getOpcodeNibble(opcode, 3))
+	lastByte := getOpcodeByte(opcode, 1)
+
+	cycles := vipFetchCycles
+	switch getOpcodeNibble(opcode, 0) {
+	case 0x0:
+		switch lastByte {
+		case 0xE0:
+			cycles += 24 + 12*256 // clears all 256 bytes of display memory
+		case 0xEE:
+			cycles += 10
+		default:
+			cycles += 10
+		}
+	case 0x1:
+		cycles += 12
+	case 0x2:
+		cycles += 26
+	case 0x3, 0x4:
+		cycles += 10
+	case 0x5, 0x9:
+		cycles += 14
+	case 0x6:
+		cycles += 6
+	case 0x7:
+		cycles += 10
+	case 0x8:
+		cycles += 44
+	case 0xA:
+		cycles += 12
+	case 0xB:
+		cycles += 22
+	case 0xC:
+		cycles += 36
+	case 0xD:
+		// each sprite row is shifted into place. rows that straddle two display bytes take twice as long
+		rowCycles := 34
+		if chip.V[x]%8 != 0 {
+			rowCycles = 68
+		}
+		cycles += 26 + n*rowCycles
+	case 0xE:
+		cycles += 14
+	case 0xF:
+		switch lastByte {
+		case 0x0A:
+			cycles += 26
+		case 0x1E:
+			cycles += 16
+		case 0x29:
+			cycles += 16
+		case 0x33:
+			// the VIP converts to decimal by repeated subtraction, so every unit of each digit costs a pass through the loop
+			val := int(chip.V[x])
+			cycles += 80 + 16*(val/100+(val/10)%10+val%10)
+		case 0x55, 0x65:
+			cycles += 14 + 14*(int(x)+1)
+		default:
+			cycles += 10
+		}
+	}
+	return cycles
+}
+
+// runCycles ... Emulates a single 60Hz frame using the COSMAC VIP timing model. Instructions are executed until they have used up the frame's
+// CyclesPerFrame machine cycles, with any overrun charged to the next frame. Dxyn waits for the vertical blank, so it ends the frame
+// and its drawing time is charged to the next frame
+func (chip *Chip8) runCycles() error {
+	chip.cycles += chip.CyclesPerFrame
+	for chip.cycles > 0 && !chip.exited {
+		pc := chip.PC
+		var opcode uint16
+		if int(pc)+1 < chip.memSize() {
+			opcode = uint16(chip.MEM[pc])<<8 | uint16(chip.MEM[pc+1])
+		}
+		cost := chip.vipCycles(opcode)
+
+		if err := chip.Step(); err != nil {
+			if err = chip.handleError(err); err != nil {
+				return err
+			}
+		}
+		switch getOpcodeNibble(opcode, 0) {
+		case 0x3, 0x4, 0x5, 0x9, 0xE:
+			if chip.PC > pc+2 {
+				cost += vipSkipCycles
+			}
+		}
+
+		if getOpcodeNibble(opcode, 0) == 0xD || chip.vblankWait {
+			chip.vblankWait = false
+			chip.cycles = -cost
+			break
+		}
+		chip.cycles -= cost
+	}
+	return nil
+}
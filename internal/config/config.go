@@ -6,7 +6,25 @@ type Config struct {
 	FgColor               uint32
 	BgColor               uint32
 	InstructionsPerSecond uint32
-	CosmacCompatible      bool
-	VerticalWrapping      bool
+	Timing                string
+	CyclesPerFrame        int
+	Palette               []uint32
+	Renderer              string
+	Scale                 int
+	Persistence           int
+	TermColors            int
+	WebAddress            string
 	ProgramPath           string
+	OnError               string
+	RewindDepth           int
+	FrameSkip             int
+	ShowStats             bool
+	ScreenshotDir         string
+	ScreenshotScale       int
+	CaptureFormat         string
+	CaptureScale          int
+	CaptureDecimation     int
+	Random                string
+	RandomSeed            uint64
+	Quirks                QuirksConfig
 }
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Quirks ... Holds every individually togglable behaviour that differs between chip-8 platforms, as well as the instruction set extensions the platform supports
+type Quirks struct {
+	// VFReset ... AND, OR and XOR (8xy1, 8xy2, 8xy3) reset VF to zero
+	VFReset bool
+	// MemoryIncrement ... Fx55 and Fx65 leave I pointing past the last register stored or loaded
+	MemoryIncrement bool
+	// DisplayWait ... Dxyn waits for the next 60Hz vertical blank before the program continues
+	DisplayWait bool
+	// ClipX ... Sprites are clipped at the right edge of the display instead of wrapping to the left edge
+	ClipX bool
+	// ClipY ... Sprites are clipped at the bottom edge of the display instead of wrapping to the top edge
+	ClipY bool
+	// ShiftVY ... 8xy6 and 8xyE copy V[y] into V[x] before shifting instead of shifting V[x] in place
+	ShiftVY bool
+	// JumpVX ... Bxnn jumps to xnn + V[x] instead of Bnnn jumping to nnn + V[0]
+	JumpVX bool
+	// ScrollHalfPixels ... 00Cn, 00FB and 00FC scroll by half a pixel in low resolution mode, as the HP48 SCHIP 1.1 interpreter did
+	ScrollHalfPixels bool
+	// SuperChip ... Enables the SUPER-CHIP instructions and the 128x64 high resolution display
+	SuperChip bool
+	// XOChip ... Enables the XO-CHIP instructions, 64KB of memory and the second bitplane
+	XOChip bool
+}
+
+// Presets ... Named Quirks profiles for common chip-8 platforms. Selected with the Preset key of the [quirks] table in chip8.toml
+var Presets map[string]Quirks = map[string]Quirks{
+	"cosmac": {
+		VFReset:         true,
+		MemoryIncrement: true,
+		DisplayWait:     true,
+		ClipX:           true,
+		ClipY:           true,
+		ShiftVY:         true,
+	},
+	"chip48": {
+		MemoryIncrement: true,
+		ClipX:           true,
+		ClipY:           true,
+		JumpVX:          true,
+	},
+	"schip10": {
+		MemoryIncrement:  true,
+		ClipX:            true,
+		ClipY:            true,
+		JumpVX:           true,
+		ScrollHalfPixels: true,
+		SuperChip:        true,
+	},
+	"schip11": {
+		ClipX:            true,
+		ClipY:            true,
+		JumpVX:           true,
+		ScrollHalfPixels: true,
+		SuperChip:        true,
+	},
+	"schipmodern": {
+		ClipX:     true,
+		ClipY:     true,
+		JumpVX:    true,
+		SuperChip: true,
+	},
+	"xochip": {
+		MemoryIncrement: true,
+		ShiftVY:         true,
+		SuperChip:       true,
+		XOChip:          true,
+	},
+}
+
+// DefaultPreset ... The preset used when chip8.toml doesnt specify one
+const DefaultPreset = "cosmac"
+
+// QuirksConfig ... The [quirks] table of chip8.toml. Preset names an entry in Presets, and any other key that is set overrides that preset's behaviour
+type QuirksConfig struct {
+	Preset           string
+	VFReset          *bool
+	MemoryIncrement  *bool
+	DisplayWait      *bool
+	ClipX            *bool
+	ClipY            *bool
+	ShiftVY          *bool
+	JumpVX           *bool
+	ScrollHalfPixels *bool
+}
+
+// Resolve ... Looks up the configured preset and applies any overrides to it. returns an error if the preset doesnt exist
+func (qc QuirksConfig) Resolve() (Quirks, error) {
+	name := strings.ToLower(qc.Preset)
+	if name == "" {
+		name = DefaultPreset
+	}
+	quirks, ok := Presets[name]
+	if !ok {
+		names := make([]string, 0, len(Presets))
+		for preset := range Presets {
+			names = append(names, preset)
+		}
+		sort.Strings(names)
+		return Quirks{}, fmt.Errorf("error in config/QuirksConfig.Resolve(): unknown quirks preset %q. valid presets: %s", qc.Preset, strings.Join(names, ", "))
+	}
+
+	override(&quirks.VFReset, qc.VFReset)
+	override(&quirks.MemoryIncrement, qc.MemoryIncrement)
+	override(&quirks.DisplayWait, qc.DisplayWait)
+	override(&quirks.ClipX, qc.ClipX)
+	override(&quirks.ClipY, qc.ClipY)
+	override(&quirks.ShiftVY, qc.ShiftVY)
+	override(&quirks.JumpVX, qc.JumpVX)
+	override(&quirks.ScrollHalfPixels, qc.ScrollHalfPixels)
+	return quirks, nil
+}
+
+// override ... Replaces the value at dst with the value at src if src is set
+func override(dst *bool, src *bool) {
+	if src != nil {
+		*dst = *src
+	}
+}
@@ -0,0 +1,68 @@
+package fonts
+
+import (
+	"embed"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+//go:embed fonts/*
+var fontFiles embed.FS
+
+// Small ... Returns the 4x5 hex digit font of the given interpreter: chip48, cosmac, dream or eti. Unknown names fall back on chip48
+func Small(name string) ([]byte, error) {
+	var path string
+	switch name {
+	case "cosmac":
+		path = "fonts/cosmacvipfont.txt"
+	case "dream":
+		path = "fonts/dream6800font.txt"
+	case "eti":
+		path = "fonts/eti660font.txt"
+	default:
+		path = "fonts/chip48font.txt"
+	}
+	return load(path)
+}
+
+// Big ... Returns the SUPER-CHIP 8x10 hex digit font
+func Big() ([]byte, error) {
+	return load("fonts/schipbigfont.txt")
+}
+
+func load(path string) ([]byte, error) {
+	rawFontData, err := fontFiles.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error in fonts/load(): %w", err)
+	}
+	return Parse(rawFontData)
+}
+
+// Parse ... Converts the contents of a font file (a "0x<char>:" header followed by one "0b<bits>" line per sprite row) to raw font bytes
+func Parse(rawFontData []byte) ([]byte, error) {
+	fontString := string(rawFontData)
+	fontStringArr := make([]string, 0)
+
+	segments := strings.Split(fontString, "0x")
+	for _, seg := range segments {
+		lines := strings.Split(seg, "\n")
+		for _, line := range lines {
+			if !strings.Contains(line, ":") && line != "" {
+				str := strings.TrimSuffix(strings.TrimPrefix(line, "0b"), "\r")
+				fontStringArr = append(fontStringArr, str)
+			}
+		}
+	}
+
+	fontBytes := make([]byte, 0)
+	for _, str := range fontStringArr {
+		val, err := strconv.ParseUint(str, 2, 8)
+		if err != nil {
+			return nil, fmt.Errorf("error in fonts/Parse(): couldnt convert font to binary: %w", err)
+		}
+		fontBytes = append(fontBytes, byte(val))
+	}
+
+	return fontBytes, nil
+}
@@ -0,0 +1,275 @@
+package framebuffer
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+const (
+	// NumPlanes ... The number of bitplanes in a framebuffer. CHIP-8 and SUPER-CHIP only draw to the first, XO-CHIP to both
+	NumPlanes = 2
+	// MaxWidth ... The widest framebuffer supported, the SUPER-CHIP's 128 pixel high resolution mode
+	MaxWidth = maxStride * 64
+	// maxStride ... The most 64-bit words a single row can take up
+	maxStride = 2
+)
+
+// Framebuffer ... A bit-packed display with a bitplane for each of the XO-CHIP's planes. Should be instantiated using framebuffer.New()
+// Each row of each plane is stored as stride 64-bit words. the leftmost pixel of a row is the most significant bit of its first word,
+// and bits past the right edge of the display are always zero
+// edge masks off the bits of each word of a row that are past the right edge
+type Framebuffer struct {
+	width  int
+	height int
+	stride int
+	planes [NumPlanes][]uint64
+	edge   [maxStride]uint64
+}
+
+// New ... Creates a blank framebuffer of the given size. width may be at most MaxWidth
+func New(height, width int) (*Framebuffer, error) {
+	if height <= 0 || width <= 0 || width > MaxWidth {
+		return nil, fmt.Errorf("error in framebuffer/New(): invalid size %dx%d. width must be between 1 and %d", width, height, MaxWidth)
+	}
+	fb := Framebuffer{
+		width:  width,
+		height: height,
+		stride: (width + 63) / 64,
+	}
+	for plane := range fb.planes {
+		fb.planes[plane] = make([]uint64, height*fb.stride)
+	}
+	for i := range fb.edge {
+		switch remaining := width - i*64; {
+		case remaining >= 64:
+			fb.edge[i] = ^uint64(0)
+		case remaining > 0:
+			fb.edge[i] = ^uint64(0) << (64 - remaining)
+		}
+	}
+	return &fb, nil
+}
+
+// Width ... Returns the width of the framebuffer in pixels
+func (fb *Framebuffer) Width() int {
+	return fb.width
+}
+
+// Height ... Returns the height of the framebuffer in pixels
+func (fb *Framebuffer) Height() int {
+	return fb.height
+}
+
+// row ... Returns the words making up a row of a plane
+func (fb *Framebuffer) row(plane, row int) []uint64 {
+	return fb.planes[plane][row*fb.stride : (row+1)*fb.stride]
+}
+
+// Get ... Returns true if the pixel at the given row and column of a plane is lit. Out of bounds pixels are never lit
+func (fb *Framebuffer) Get(plane, row, col int) bool {
+	if plane < 0 || plane >= NumPlanes || row < 0 || row >= fb.height || col < 0 || col >= fb.width {
+		return false
+	}
+	return fb.planes[plane][row*fb.stride+col/64]&(1<<(63-col%64)) != 0
+}
+
+// Set ... Lights or clears the pixel at the given row and column of a plane. Out of bounds pixels are ignored
+func (fb *Framebuffer) Set(plane, row, col int, lit bool) {
+	if plane < 0 || plane >= NumPlanes || row < 0 || row >= fb.height || col < 0 || col >= fb.width {
+		return
+	}
+	bit := uint64(1) << (63 - col%64)
+	if lit {
+		fb.planes[plane][row*fb.stride+col/64] |= bit
+	} else {
+		fb.planes[plane][row*fb.stride+col/64] &^= bit
+	}
+}
+
+// Pixel ... Returns a bitmask of the planes lit at the given row and column: bit 0 for the first plane, bit 1 for the second
+func (fb *Framebuffer) Pixel(row, col int) byte {
+	var mask byte
+	for plane := 0; plane < NumPlanes; plane++ {
+		if fb.Get(plane, row, col) {
+			mask |= 1 << plane
+		}
+	}
+	return mask
+}
+
+// Clear ... Turns off every pixel of a plane
+func (fb *Framebuffer) Clear(plane int) {
+	clear(fb.planes[plane])
+}
+
+// XorRow ... XORs a row of sprite pixels onto a row of a plane, starting at column col. Returns true if any lit pixel was turned off.
+// The sprite row is the lowest n bits of pixels, with the leftmost pixel in bit n-1. n may be at most 64.
+// Pixels past the right edge wrap around to the left edge if wrap is true, and are dropped otherwise
+func (fb *Framebuffer) XorRow(plane, row, col int, pixels uint64, n int, wrap bool) bool {
+	if row < 0 || row >= fb.height || col < 0 || col >= fb.width || n <= 0 {
+		return false
+	}
+	aligned := pixels << (64 - n)
+	var mask [maxStride]uint64
+	place(&mask, aligned, col)
+	if wrap && col+n > fb.width {
+		place(&mask, aligned<<(fb.width-col), 0)
+	}
+
+	collision := false
+	words := fb.row(plane, row)
+	for i := range words {
+		m := mask[i] & fb.edge[i]
+		collision = collision || words[i]&m != 0
+		words[i] ^= m
+	}
+	return collision
+}
+
+// place ... ORs a row of pixels aligned to the most significant bit into mask, starting at column col
+func place(mask *[maxStride]uint64, aligned uint64, col int) {
+	word, offset := col/64, col%64
+	mask[word] |= aligned >> offset
+	if offset != 0 && word+1 < maxStride {
+		mask[word+1] |= aligned << (64 - offset)
+	}
+}
+
+// Scroll ... Moves the contents of a plane down by dRow rows and right by dCol columns (negative values move up and left).
+// Pixels scrolled in from the edges are turned off
+func (fb *Framebuffer) Scroll(plane, dRow, dCol int) {
+	pixels := fb.planes[plane]
+	if dRow != 0 {
+		shift := dRow * fb.stride
+		switch {
+		case dRow >= fb.height || -dRow >= fb.height:
+			clear(pixels)
+		case dRow > 0:
+			copy(pixels[shift:], pixels[:len(pixels)-shift])
+			clear(pixels[:shift])
+		default:
+			copy(pixels, pixels[-shift:])
+			clear(pixels[len(pixels)+shift:])
+		}
+	}
+	if dCol != 0 {
+		for row := 0; row < fb.height; row++ {
+			words := fb.row(plane, row)
+			shiftRow(words, dCol)
+			for i := range words {
+				words[i] &= fb.edge[i]
+			}
+		}
+	}
+}
+
+// shiftRow ... Shifts a row of words right by n bits (left if n is negative), as if it were one big number with the first word most significant
+func shiftRow(words []uint64, n int) {
+	if n >= 64*len(words) || -n >= 64*len(words) {
+		clear(words)
+		return
+	}
+	for ; n >= 64; n -= 64 {
+		copy(words[1:], words)
+		words[0] = 0
+	}
+	for ; n <= -64; n += 64 {
+		copy(words, words[1:])
+		words[len(words)-1] = 0
+	}
+	switch {
+	case n > 0:
+		for i := len(words) - 1; i > 0; i-- {
+			words[i] = words[i]>>n | words[i-1]<<(64-n)
+		}
+		words[0] >>= n
+	case n < 0:
+		n = -n
+		for i := 0; i < len(words)-1; i++ {
+			words[i] = words[i]<<n | words[i+1]>>(64-n)
+		}
+		words[len(words)-1] <<= n
+	}
+}
+
+// Lit ... Returns the number of lit pixels on a plane
+func (fb *Framebuffer) Lit(plane int) int {
+	count := 0
+	for _, word := range fb.planes[plane] {
+		count += bits.OnesCount64(word)
+	}
+	return count
+}
+
+// Clone ... Returns a copy of the framebuffer that shares no memory with it
+func (fb *Framebuffer) Clone() *Framebuffer {
+	clone := *fb
+	for plane := range fb.planes {
+		clone.planes[plane] = append([]uint64(nil), fb.planes[plane]...)
+	}
+	return &clone
+}
+
+// CopyFrom ... Overwrites the framebuffer's pixels with src's. src must be the same size
+func (fb *Framebuffer) CopyFrom(src *Framebuffer) {
+	for plane := range fb.planes {
+		copy(fb.planes[plane], src.planes[plane])
+	}
+}
+
+// Equal ... Returns true if other is the same size and has the same pixels lit
+func (fb *Framebuffer) Equal(other *Framebuffer) bool {
+	if fb.width != other.width || fb.height != other.height {
+		return false
+	}
+	for plane := range fb.planes {
+		for i, word := range fb.planes[plane] {
+			if other.planes[plane][i] != word {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// PackedLen ... Returns the number of bytes Pack returns
+func (fb *Framebuffer) PackedLen() int {
+	return NumPlanes * fb.height * ((fb.width + 7) / 8)
+}
+
+// Pack ... Returns every plane packed into one bit per pixel, row by row, with each row padded to a whole byte
+func (fb *Framebuffer) Pack() []byte {
+	rowLen := (fb.width + 7) / 8
+	packed := make([]byte, 0, fb.PackedLen())
+	for plane := range fb.planes {
+		for row := 0; row < fb.height; row++ {
+			words := fb.row(plane, row)
+			for i := 0; i < rowLen; i++ {
+				packed = append(packed, byte(words[i/8]>>(56-8*(i%8))))
+			}
+		}
+	}
+	return packed
+}
+
+// Unpack ... Replaces every plane with pixels packed by Pack. returns an error if packed is the wrong length
+func (fb *Framebuffer) Unpack(packed []byte) error {
+	if len(packed) != fb.PackedLen() {
+		return fmt.Errorf("error in framebuffer/Framebuffer.Unpack(): expected %d bytes, got %d", fb.PackedLen(), len(packed))
+	}
+	rowLen := (fb.width + 7) / 8
+	for plane := range fb.planes {
+		for row := 0; row < fb.height; row++ {
+			words := fb.row(plane, row)
+			clear(words)
+			for i := 0; i < rowLen; i++ {
+				words[i/8] |= uint64(packed[0]) << (56 - 8*(i%8))
+				packed = packed[1:]
+			}
+			for i := range words {
+				words[i] &= fb.edge[i]
+			}
+		}
+	}
+	return nil
+}
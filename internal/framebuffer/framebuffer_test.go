@@ -0,0 +1,183 @@
+package framebuffer
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+)
+
+// pixel ... The row and column of a lit pixel
+type pixel struct{ row, col int }
+
+// newLit ... Creates a framebuffer of the given size with the given pixels lit on plane 0
+func newLit(t *testing.T, height, width int, lit []pixel) *Framebuffer {
+	t.Helper()
+	fb, err := New(height, width)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range lit {
+		fb.Set(0, p.row, p.col, true)
+	}
+	return fb
+}
+
+// litPixels ... Returns every pixel lit on a plane, row by row
+func litPixels(fb *Framebuffer, plane int) []pixel {
+	var lit []pixel
+	for row := 0; row < fb.Height(); row++ {
+		for col := 0; col < fb.Width(); col++ {
+			if fb.Get(plane, row, col) {
+				lit = append(lit, pixel{row, col})
+			}
+		}
+	}
+	return lit
+}
+
+// span ... Returns the pixels of row from column first to last inclusive
+func span(row, first, last int) []pixel {
+	var pixels []pixel
+	for col := first; col <= last; col++ {
+		pixels = append(pixels, pixel{row, col})
+	}
+	return pixels
+}
+
+func TestNewRejectsInvalidSizes(t *testing.T) {
+	for _, size := range []struct{ height, width int }{{0, 64}, {32, 0}, {-1, 64}, {64, MaxWidth + 1}} {
+		if _, err := New(size.height, size.width); err == nil {
+			t.Errorf("New(%d, %d) error = nil, want an error", size.height, size.width)
+		}
+	}
+}
+
+func TestXorRow(t *testing.T) {
+	tests := []struct {
+		name          string
+		width         int
+		lit           []pixel
+		row, col      int
+		pixels        uint64
+		n             int
+		wrap          bool
+		want          []pixel
+		wantCollision bool
+	}{
+		{name: "inside the first word", width: 128, col: 3, pixels: 0b1011, n: 4, want: []pixel{{0, 3}, {0, 5}, {0, 6}}},
+		{name: "across the word boundary", width: 128, col: 60, pixels: 0xFF, n: 8, want: span(0, 60, 67)},
+		{name: "a whole word across the boundary", width: 128, row: 5, col: 32, pixels: ^uint64(0), n: 64, want: span(5, 32, 95)},
+		{name: "clipped at the right edge", width: 128, col: 124, pixels: 0xFF, n: 8, want: span(0, 124, 127)},
+		{name: "wrapped at the right edge", width: 128, col: 124, pixels: 0xFF, n: 8, wrap: true, want: append(span(0, 0, 3), span(0, 124, 127)...)},
+		{name: "clipped at the edge of a one word display", width: 64, col: 60, pixels: 0xFF, n: 8, want: span(0, 60, 63)},
+		{name: "wrapped at the edge of a one word display", width: 64, col: 60, pixels: 0xFF, n: 8, wrap: true, want: append(span(0, 0, 3), span(0, 60, 63)...)},
+		{name: "clipped at the edge of a display part way through a word", width: 100, col: 96, pixels: 0xFF, n: 8, want: span(0, 96, 99)},
+		{name: "wrapped at the edge of a display part way through a word", width: 100, col: 96, pixels: 0xFF, n: 8, wrap: true, want: append(span(0, 0, 3), span(0, 96, 99)...)},
+		{name: "collision in the second word", width: 128, lit: []pixel{{0, 64}}, col: 60, pixels: 0xFF, n: 8,
+			want: append(span(0, 60, 63), span(0, 65, 67)...), wantCollision: true},
+		{name: "no collision beside a lit pixel", width: 128, lit: []pixel{{0, 59}, {0, 68}}, col: 60, pixels: 0xFF, n: 8,
+			want: append([]pixel{{0, 59}}, span(0, 60, 68)...), wantCollision: false},
+		{name: "collision with a wrapped pixel", width: 64, lit: []pixel{{0, 1}}, col: 60, pixels: 0xFF, n: 8, wrap: true,
+			want: []pixel{{0, 0}, {0, 2}, {0, 3}, {0, 60}, {0, 61}, {0, 62}, {0, 63}}, wantCollision: true},
+		{name: "no collision with a clipped pixel", width: 64, lit: []pixel{{0, 1}}, col: 60, pixels: 0xFF, n: 8,
+			want: append([]pixel{{0, 1}}, span(0, 60, 63)...), wantCollision: false},
+		{name: "the bottom row", width: 128, row: 63, col: 0, pixels: 1, n: 1, want: []pixel{{63, 0}}},
+		{name: "below the bottom edge", width: 128, row: 64, col: 0, pixels: 0xFF, n: 8},
+		{name: "past the right edge", width: 128, col: 128, pixels: 0xFF, n: 8, wrap: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fb := newLit(t, 64, tt.width, tt.lit)
+			collision := fb.XorRow(0, tt.row, tt.col, tt.pixels, tt.n, tt.wrap)
+			if collision != tt.wantCollision {
+				t.Errorf("XorRow() = %v, want %v", collision, tt.wantCollision)
+			}
+			if got := litPixels(fb, 0); !slices.Equal(got, tt.want) {
+				t.Errorf("lit pixels = %v, want %v", got, tt.want)
+			}
+			if fb.Lit(1) != 0 {
+				t.Error("XorRow() on plane 0 lit pixels on plane 1")
+			}
+		})
+	}
+}
+
+func TestScroll(t *testing.T) {
+	tests := []struct {
+		name       string
+		width      int
+		lit        []pixel
+		dRow, dCol int
+		want       []pixel
+	}{
+		{name: "down", width: 128, lit: []pixel{{0, 0}, {61, 5}}, dRow: 4, want: []pixel{{4, 0}}},
+		{name: "up", width: 128, lit: []pixel{{2, 0}, {10, 127}}, dRow: -4, want: []pixel{{6, 127}}},
+		{name: "right across the word boundary", width: 128, lit: []pixel{{3, 62}, {3, 126}}, dCol: 4, want: []pixel{{3, 66}}},
+		{name: "left across the word boundary", width: 128, lit: []pixel{{3, 2}, {3, 65}}, dCol: -4, want: []pixel{{3, 61}}},
+		{name: "right by a whole word", width: 128, lit: []pixel{{1, 10}, {1, 64}}, dCol: 64, want: []pixel{{1, 74}}},
+		{name: "left by more than a word", width: 128, lit: []pixel{{2, 99}, {2, 110}}, dCol: -100, want: []pixel{{2, 10}}},
+		{name: "right off the edge of a display part way through a word", width: 100, lit: []pixel{{0, 90}, {0, 97}}, dCol: 4, want: []pixel{{0, 94}}},
+		{name: "left on a display part way through a word", width: 100, lit: []pixel{{0, 0}, {0, 99}}, dCol: -1, want: []pixel{{0, 98}}},
+		{name: "down and right at once", width: 64, lit: []pixel{{0, 0}, {63, 63}}, dRow: 1, dCol: 1, want: []pixel{{1, 1}}},
+		{name: "down past the bottom edge", width: 128, lit: []pixel{{0, 0}, {63, 127}}, dRow: 64},
+		{name: "right past the right edge", width: 128, lit: []pixel{{0, 0}, {63, 127}}, dCol: 128},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fb := newLit(t, 64, tt.width, tt.lit)
+			fb.Set(1, 0, 0, true)
+			fb.Scroll(0, tt.dRow, tt.dCol)
+			if got := litPixels(fb, 0); !slices.Equal(got, tt.want) {
+				t.Errorf("lit pixels = %v, want %v", got, tt.want)
+			}
+			if !fb.Get(1, 0, 0) || fb.Lit(1) != 1 {
+				t.Error("Scroll() of plane 0 moved plane 1")
+			}
+		})
+	}
+}
+
+func TestPack(t *testing.T) {
+	fb := newLit(t, 2, 12, []pixel{{0, 0}, {0, 11}})
+	fb.Set(1, 1, 8, true)
+	// each 12 pixel row takes 2 bytes, and the second plane follows the first
+	want := []byte{0x80, 0x10, 0x00, 0x00, 0x00, 0x00, 0x00, 0x80}
+	if fb.PackedLen() != len(want) {
+		t.Errorf("PackedLen() = %d, want %d", fb.PackedLen(), len(want))
+	}
+	if got := fb.Pack(); !bytes.Equal(got, want) {
+		t.Errorf("Pack() = % x, want % x", got, want)
+	}
+}
+
+func TestPackUnpackRoundTrip(t *testing.T) {
+	for _, width := range []int{64, 100, 128} {
+		fb := newLit(t, 64, width, []pixel{{0, 0}, {5, 63}, {5, 64 % width}, {63, width - 1}})
+		fb.Set(1, 31, width/2, true)
+		unpacked, err := New(64, width)
+		if err != nil {
+			t.Fatal(err)
+		}
+		unpacked.Set(0, 1, 1, true)
+		if err := unpacked.Unpack(fb.Pack()); err != nil {
+			t.Fatal(err)
+		}
+		if !unpacked.Equal(fb) {
+			t.Errorf("width %d: Unpack(Pack()) = %v and %v, want %v and %v", width, litPixels(unpacked, 0), litPixels(unpacked, 1), litPixels(fb, 0), litPixels(fb, 1))
+		}
+	}
+}
+
+func TestUnpack(t *testing.T) {
+	fb := newLit(t, 2, 12, nil)
+	if err := fb.Unpack(make([]byte, 7)); err == nil {
+		t.Error("Unpack() of too few bytes error = nil, want an error")
+	}
+	// the padding bits past the right edge are dropped, so they cant be counted as lit
+	if err := fb.Unpack([]byte{0x00, 0xFF, 0, 0, 0, 0, 0, 0}); err != nil {
+		t.Fatal(err)
+	}
+	if want := span(0, 8, 11); !slices.Equal(litPixels(fb, 0), want) || fb.Lit(0) != len(want) {
+		t.Errorf("lit pixels = %v with Lit() = %d, want %v", litPixels(fb, 0), fb.Lit(0), want)
+	}
+}
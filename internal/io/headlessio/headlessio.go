@@ -0,0 +1,101 @@
+package headlessio
+
+import (
+	"github.com/TH3-F001/GoChip-8/chip8/internal/framebuffer"
+	"github.com/TH3-F001/GoChip-8/chip8/internal/io"
+)
+
+// FrameFunc ... Called with every frame presented. frame is the number of frames completed, the same as chip8.Chip8.Frame once the frame has run.
+// fb belongs to the HeadlessIO and is only valid until the next frame, so it must be cloned to keep it
+type FrameFunc func(frame uint64, fb *framebuffer.Framebuffer)
+
+// HeadlessIO ... An io.IO backend without a terminal or window, for tests and batch jobs. Should be instantiated using headlessio.New()
+// The last frame presented is kept in memory, and the keypad is driven by a Script, one frame at a time
+// Every method is called by the chip8 on the emulation goroutine, so none of them are safe for concurrent use
+// script is the keypad input, and next the index of its next event. onFrame is called with every frame presented
+// fb is a copy of the last frame presented, and frame the frame currently being emulated
+// keys is the keypad state the script presses and releases keys in, driven frame by frame
+// terminated is true once Terminate is called
+type HeadlessIO struct {
+	script  *Script
+	next    int
+	onFrame FrameFunc
+
+	fb    *framebuffer.Framebuffer
+	frame uint64
+
+	keys *io.KeyState
+
+	terminated bool
+}
+
+// New ... Creates a HeadlessIO that takes its keypad input from script and calls onFrame with every frame presented. Either can be nil
+func New(script *Script, onFrame FrameFunc) *HeadlessIO {
+	if script == nil {
+		script = &Script{}
+	}
+	return &HeadlessIO{
+		script:  script,
+		onFrame: onFrame,
+		keys:    io.NewKeyState(0),
+	}
+}
+
+// BeginFrame ... Presses and releases the keys the script has for this frame, and any before it that were skipped
+func (h *HeadlessIO) BeginFrame(frame uint64) {
+	h.frame = frame
+	h.keys.BeginFrame(frame)
+	for ; h.next < len(h.script.Events) && h.script.Events[h.next].Frame <= frame; h.next++ {
+		if event := h.script.Events[h.next]; event.Down {
+			h.keys.Press(event.Key)
+		} else {
+			h.keys.Release(event.Key)
+		}
+	}
+}
+
+// Present ... Keeps a copy of a completed frame and passes it to the FrameFunc, if there is one
+func (h *HeadlessIO) Present(fb *framebuffer.Framebuffer) error {
+	if h.fb != nil && h.fb.Width() == fb.Width() && h.fb.Height() == fb.Height() {
+		h.fb.CopyFrom(fb)
+	} else {
+		h.fb = fb.Clone()
+	}
+	if h.onFrame != nil {
+		h.onFrame(h.frame+1, h.fb)
+	}
+	return nil
+}
+
+// Framebuffer ... Returns the last frame presented, or nil if there hasnt been one
+func (h *HeadlessIO) Framebuffer() *framebuffer.Framebuffer {
+	return h.fb
+}
+
+// ListenWait ... Returns a key the script pressed since the wait began, or io.NoKey. Fx0A repeats until it gets a key, so never blocks
+func (h *HeadlessIO) ListenWait() (byte, error) {
+	return h.keys.ListenWait()
+}
+
+// ListenNow ... Returns the last key the script pressed if it is still held down, or any other key it holds. io.NoKey if none are
+func (h *HeadlessIO) ListenNow() (byte, error) {
+	return h.keys.ListenNow()
+}
+
+// KeyDown ... Returns true if the script is holding the given hex key down
+func (h *HeadlessIO) KeyDown(key byte) bool {
+	return h.keys.KeyDown(key)
+}
+
+// ListenForControl ... Does nothing, as there is no keyboard to listen to. Whoever runs the chip should stop it at the script's End, such as with runner.Runner.StopAtFrame
+func (h *HeadlessIO) ListenForControl(termCh chan<- bool) {}
+
+// Terminated ... Returns true once Terminate has been called
+func (h *HeadlessIO) Terminated() bool {
+	return h.terminated
+}
+
+// Terminate ... Marks the backend as terminated
+func (h *HeadlessIO) Terminate() {
+	h.terminated = true
+}
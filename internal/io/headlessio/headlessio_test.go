@@ -0,0 +1,138 @@
+package headlessio
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/TH3-F001/GoChip-8/chip8/internal/framebuffer"
+	"github.com/TH3-F001/GoChip-8/chip8/internal/io"
+)
+
+// newScripted ... Creates a HeadlessIO driven by the given script text
+func newScripted(t *testing.T, text string) *HeadlessIO {
+	t.Helper()
+	script, err := ParseScript(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return New(script, nil)
+}
+
+func TestScriptDrivesKeypad(t *testing.T) {
+	h := newScripted(t, "2 +5\n3 +7\n4 -7\n6 -5\n")
+	tests := []struct {
+		frame    uint64
+		wantDown []byte
+		wantNow  byte
+	}{
+		{frame: 1, wantNow: io.NoKey},
+		{frame: 2, wantDown: []byte{0x5}, wantNow: 0x5},
+		{frame: 3, wantDown: []byte{0x5, 0x7}, wantNow: 0x7},
+		// releasing the last key pressed falls back on another key still held
+		{frame: 4, wantDown: []byte{0x5}, wantNow: 0x5},
+		{frame: 5, wantDown: []byte{0x5}, wantNow: 0x5},
+		{frame: 6, wantNow: io.NoKey},
+	}
+	for _, tt := range tests {
+		h.BeginFrame(tt.frame)
+		for key := byte(0); key < 16; key++ {
+			want := false
+			for _, down := range tt.wantDown {
+				want = want || down == key
+			}
+			if got := h.KeyDown(key); got != want {
+				t.Errorf("frame %d: KeyDown(%#x) = %v, want %v", tt.frame, key, got, want)
+			}
+		}
+		if got, _ := h.ListenNow(); got != tt.wantNow {
+			t.Errorf("frame %d: ListenNow() = %#x, want %#x", tt.frame, got, tt.wantNow)
+		}
+	}
+}
+
+func TestSkippedFramesCatchUp(t *testing.T) {
+	h := newScripted(t, "2 +5\n3 +7\n4 -7\n")
+	h.BeginFrame(10)
+	if !h.KeyDown(0x5) || h.KeyDown(0x7) {
+		t.Error("events of skipped frames werent all applied, in order")
+	}
+}
+
+func TestListenWait(t *testing.T) {
+	tests := []struct {
+		name      string
+		script    string
+		waitFrom  uint64
+		waitTo    uint64
+		wantKey   byte
+		wantFrame uint64
+	}{
+		{name: "a press during the wait ends it", script: "5 +A\n", waitFrom: 2, waitTo: 8, wantKey: 0xA, wantFrame: 5},
+		{name: "a press on the frame the wait starts ends it", script: "2 +A\n", waitFrom: 2, waitTo: 8, wantKey: 0xA, wantFrame: 2},
+		{name: "a press on frame 0 ends a wait started on it", script: "0 +A\n", waitFrom: 0, waitTo: 8, wantKey: 0xA, wantFrame: 0},
+		{name: "a press before the wait doesnt", script: "1 +A\n", waitFrom: 2, waitTo: 8, wantKey: io.NoKey},
+		{name: "holding a key down doesnt press it again", script: "1 +A\n4 +A\n", waitFrom: 2, waitTo: 8, wantKey: io.NoKey},
+		{name: "pressing it again after a release does", script: "1 +A\n4 -A\n6 +A\n", waitFrom: 2, waitTo: 8, wantKey: 0xA, wantFrame: 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newScripted(t, tt.script)
+			for frame := uint64(0); frame < tt.waitFrom; frame++ {
+				h.BeginFrame(frame)
+			}
+			for frame := tt.waitFrom; frame <= tt.waitTo; frame++ {
+				h.BeginFrame(frame)
+				// Fx0A asks over and over within a frame
+				key, _ := h.ListenWait()
+				again, _ := h.ListenWait()
+				if key != io.NoKey || again != io.NoKey {
+					if key != tt.wantKey || frame != tt.wantFrame {
+						t.Fatalf("ListenWait() = %#x on frame %d, want %#x on frame %d", key, frame, tt.wantKey, tt.wantFrame)
+					}
+					if again != io.NoKey {
+						t.Fatalf("ListenWait() returned %#x twice", again)
+					}
+					return
+				}
+			}
+			if tt.wantKey != io.NoKey {
+				t.Errorf("ListenWait() never returned %#x", tt.wantKey)
+			}
+		})
+	}
+}
+
+func TestPresentKeepsFrame(t *testing.T) {
+	var frames []uint64
+	h := New(nil, func(frame uint64, fb *framebuffer.Framebuffer) { frames = append(frames, frame) })
+	if h.Framebuffer() != nil {
+		t.Error("Framebuffer() before the first frame isnt nil")
+	}
+	fb, err := framebuffer.New(32, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fb.Set(0, 1, 2, true)
+	h.BeginFrame(0)
+	if err := h.Present(fb); err != nil {
+		t.Fatal(err)
+	}
+	// the frame is copied, so the chip drawing the next one doesnt change it
+	fb.Set(0, 1, 2, false)
+	if kept := h.Framebuffer(); kept == nil || !kept.Get(0, 1, 2) {
+		t.Error("Framebuffer() doesnt hold the frame presented")
+	}
+	h.BeginFrame(1)
+	h.Present(fb)
+	if len(frames) != 2 || frames[0] != 1 || frames[1] != 2 {
+		t.Errorf("FrameFunc called with frames %v, want [1 2]", frames)
+	}
+	if h.Framebuffer().Get(0, 1, 2) {
+		t.Error("Framebuffer() still holds the first frame")
+	}
+
+	h.Terminate()
+	if !h.Terminated() {
+		t.Error("Terminated() = false after Terminate()")
+	}
+}
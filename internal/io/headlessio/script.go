@@ -0,0 +1,93 @@
+package headlessio
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// exitCommand ... The word in a script that ends the run at the start of its frame
+const exitCommand = "exit"
+
+// Event ... A hex key pressed or released at the start of a frame
+type Event struct {
+	Frame uint64
+	Key   byte
+	Down  bool
+}
+
+// Script ... Keypad input for a headless run. Events are sorted by frame, and End is the frame the run ends at, or 0 to run until the program exits.
+// Scripts are text, with a frame number followed by what happens at the start of that frame on every line:
+//
+//	# comments run to the end of the line
+//	60  +5      # press 5
+//	75  -5 +6   # release 5 and press 6
+//	90  -6
+//	600 exit    # end the run
+type Script struct {
+	Events []Event
+	End    uint64
+}
+
+// LoadScript ... Reads a script from the file at path
+func LoadScript(path string) (*Script, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error in headlessio/LoadScript(): %w", err)
+	}
+	defer file.Close()
+	script, err := ParseScript(file)
+	if err != nil {
+		return nil, fmt.Errorf("error in headlessio/LoadScript(): %s: %w", path, err)
+	}
+	return script, nil
+}
+
+// ParseScript ... Reads a script from r
+func ParseScript(r io.Reader) (*Script, error) {
+	var script Script
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+		frame, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("error in headlessio/ParseScript(): line %d: invalid frame %q", line, fields[0])
+		}
+		if len(fields) == 1 {
+			return nil, fmt.Errorf("error in headlessio/ParseScript(): line %d: nothing happens on frame %d", line, frame)
+		}
+		for _, field := range fields[1:] {
+			if field == exitCommand {
+				// End is 0 when the script doesnt end the run, so the run cant end on frame 0. it would end before running anything anyway
+				if frame == 0 {
+					return nil, fmt.Errorf("error in headlessio/ParseScript(): line %d: %s must be on frame 1 or later", line, exitCommand)
+				}
+				if script.End == 0 || frame < script.End {
+					script.End = frame
+				}
+				continue
+			}
+			key, err := strconv.ParseUint(field[1:], 16, 4)
+			if len(field) != 2 || (field[0] != '+' && field[0] != '-') || err != nil {
+				return nil, fmt.Errorf("error in headlessio/ParseScript(): line %d: invalid event %q. must be +K or -K for a hex key K, or %s", line, field, exitCommand)
+			}
+			script.Events = append(script.Events, Event{Frame: frame, Key: byte(key), Down: field[0] == '+'})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error in headlessio/ParseScript(): %w", err)
+	}
+	// stable, so that events on the same frame happen in the order they were written
+	sort.SliceStable(script.Events, func(i, j int) bool {
+		return script.Events[i].Frame < script.Events[j].Frame
+	})
+	return &script, nil
+}
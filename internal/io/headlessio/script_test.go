@@ -0,0 +1,64 @@
+package headlessio
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseScript(t *testing.T) {
+	tests := []struct {
+		name       string
+		script     string
+		wantEvents []Event
+		wantEnd    uint64
+	}{
+		{name: "empty", script: ""},
+		{name: "comments and blank lines", script: "# a comment\n\n   \n60 +5 # press 5\n",
+			wantEvents: []Event{{Frame: 60, Key: 0x5, Down: true}}},
+		{name: "several events on a line, in the order written", script: "75 -5 +a\n",
+			wantEvents: []Event{{Frame: 75, Key: 0x5}, {Frame: 75, Key: 0xA, Down: true}}},
+		{name: "lines out of order are sorted by frame", script: "90 -6\n10 +6\n90 +1\n",
+			wantEvents: []Event{{Frame: 10, Key: 0x6, Down: true}, {Frame: 90, Key: 0x6}, {Frame: 90, Key: 0x1, Down: true}}},
+		{name: "exit", script: "60 +F\n600 exit\n", wantEvents: []Event{{Frame: 60, Key: 0xF, Down: true}}, wantEnd: 600},
+		{name: "the earliest exit wins", script: "600 exit\n300 exit +1\n", wantEvents: []Event{{Frame: 300, Key: 0x1, Down: true}}, wantEnd: 300},
+		{name: "exit on the first frame", script: "1 exit\n", wantEnd: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script, err := ParseScript(strings.NewReader(tt.script))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(script.Events, tt.wantEvents) {
+				t.Errorf("Events = %+v, want %+v", script.Events, tt.wantEvents)
+			}
+			if script.End != tt.wantEnd {
+				t.Errorf("End = %d, want %d", script.End, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestParseScriptErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+	}{
+		{name: "frame that isnt a number", script: "sixty +5\n"},
+		{name: "negative frame", script: "-1 +5\n"},
+		{name: "frame with nothing on it", script: "60\n"},
+		{name: "key that isnt hex", script: "60 +G\n"},
+		{name: "key without a sign", script: "60 5\n"},
+		{name: "two keys in one event", script: "60 +56\n"},
+		{name: "unknown command", script: "60 quit\n"},
+		{name: "exit on frame 0, which would mean running until the program exits", script: "0 exit\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if script, err := ParseScript(strings.NewReader(tt.script)); err == nil {
+				t.Errorf("ParseScript() = %+v, want an error", script)
+			}
+		})
+	}
+}
@@ -1,39 +1,120 @@
 package io
 
-// Why combine display and input into one? because most libraries typically handle bot in a semi coupled manner.
-// SDL requires a window to take scan codes, and TCell handles keyboard events itself, not externally
-// at the end of the day, code doesnt always reflect reality.
+import "github.com/TH3-F001/GoChip-8/chip8/internal/framebuffer"
 
-// IO ... Handles User Input, Video Output, and Sound output for the Chip-8
-type IO interface {
-
-	// GetMaxRow ... Returns the highest row that can be accessed on the display's array of pixels
-	GetMaxRow() int
-
-	// GetMaxCol ... Returns the highest column that can be accessed on the display's array of pixels
-	GetMaxCol() int
+// A chip8 is given its display, keypad and audio separately, so each can come from a different backend, or be swapped out on its own.
+// Most libraries handle the display and keyboard in a semi coupled manner though (SDL needs a window to take scan codes, and TCell handles
+// keyboard events itself), so backends like TcellIO still implement several of them at once, along with IO for running the program
 
-	// GetPixels ... returns a copy of the display's pixel array
-	GetPixels() [][]bool
+// Display ... Shows the chip8's framebuffer
+type Display interface {
 
-	// GetPixel ... Returns true if the pixel at the given cell in the pixels array is on, false if it's off, and an error if out of bounds
-	GetPixel(row, col int) (bool, error)
-
-	// SetPixel ... Sets the pixel at a given row and column to either on or off. returns an error if out of bounds
-	SetPixel(row, col int, lit bool) error
+	// Present ... Displays a completed frame and forwards any errors. fb belongs to the chip8 and is only valid until Present returns,
+	// so backends that need the frame afterwards must Clone it
+	Present(fb *framebuffer.Framebuffer) error
+}
 
-	// Refresh ... Iterates over the display's array of pixels, and updates the display to match the array. and forwards any errors
-	Refresh() error
+// Keypad ... Reports presses of the chip8's hex keypad
+type Keypad interface {
 
 	// ListenWait ... Listens for the traditonal Chip8 keypresses eturns the corresponding hex code and forwards any errors. (run concurrently for no blockers, else run vanilla)
 	ListenWait() (byte, error)
 
 	// ListenNow ... Listens for currently Chip8 keypresses returns the corresponding hex code and forwards any errors.
 	ListenNow() (byte, error)
+}
+
+// Audio ... Plays the chip8's beep
+type Audio interface {
+
+	// Sound ... Starts the beep when on is true, and stops it when false. Called when the sound timer starts and stops
+	Sound(on bool)
+}
+
+// PatternIO ... Implemented by audio backends that can play the XO-CHIP's audio pattern in place of their plain beep
+type PatternIO interface {
+	Audio
+
+	// SetPattern ... Sets the 128 1-bit samples looped while the sound is on, most significant bit of the first byte first, and the rate in Hz they are played at.
+	// A pattern of all zeros goes back to the plain beep. Called by XO-CHIP chips whenever either changes
+	SetPattern(pattern [16]byte, rate float64)
+}
+
+// BeepPattern ... The plain beep as an audio pattern, a 440Hz square wave. Played by PatternIO backends until they are given another pattern
+var BeepPattern [16]byte = [16]byte{0xF0, 0xF0, 0xF0, 0xF0, 0xF0, 0xF0, 0xF0, 0xF0, 0xF0, 0xF0, 0xF0, 0xF0, 0xF0, 0xF0, 0xF0, 0xF0}
+
+// BeepRate ... The rate in Hz BeepPattern is played at. Each byte is a whole period of the square wave
+const BeepRate float64 = 440 * 8
+
+// IO ... A frontend that provides both a Display and a Keypad, and controls the lifetime of the program around them
+type IO interface {
+	Display
+	Keypad
 
 	// ListenForTermination ... Specifically listens for user interupts to terminate the program. (meant to be run concurrently)
 	ListenForControl(termCh chan<- bool)
 
-	// Terminate ... Clears the screen, destroys it, and exits the program
+	// Terminate ... Clears the screen and destroys it. Returns once the backend has shut down, leaving the caller to exit the program
 	Terminate()
 }
+
+// KeyStateIO ... Implemented by keypads that track whether each key is held down, rather than only reporting the last key pressed.
+// SKP and SKNP use it to test the exact key they were given, so several keys can be held at once
+type KeyStateIO interface {
+	Keypad
+
+	// KeyDown ... Returns true if the given hex key is currently held down
+	KeyDown(key byte) bool
+}
+
+// Hotkey ... A frontend command that isnt a chip8 keypress, such as saving or loading a save state
+type Hotkey int
+
+const (
+	// HotkeySaveState ... Save a snapshot of the chip to the selected save slot
+	HotkeySaveState Hotkey = iota
+	// HotkeyLoadState ... Restore the chip from the snapshot in the selected save slot
+	HotkeyLoadState
+	// HotkeyNextSlot ... Select the next save slot
+	HotkeyNextSlot
+	// HotkeyPrevSlot ... Select the previous save slot
+	HotkeyPrevSlot
+	// HotkeyRewind ... Step back one frame. Sent repeatedly for as long as the rewind key is held
+	HotkeyRewind
+	// HotkeyScreenshot ... Save a screenshot of the display
+	HotkeyScreenshot
+	// HotkeyRecord ... Start recording gameplay, or stop the recording in progress
+	HotkeyRecord
+)
+
+// HotkeyIO ... Implemented by IO backends that can report hotkeys. Hotkeys are sent by the same goroutine started by ListenForControl
+type HotkeyIO interface {
+	IO
+
+	// SetHotkeyChannel ... Sets the channel hotkeys are sent to. Must be called before ListenForControl. Hotkeys are dropped if the channel is full
+	SetHotkeyChannel(hotkeyCh chan<- Hotkey)
+}
+
+// FrameIO ... Implemented by displays and keypads that need to know when each emulated frame begins, such as input recorders that latch the keypad once per frame
+type FrameIO interface {
+
+	// BeginFrame ... Called by the chip8 at the start of every frame, before any of the frame's instructions are executed.
+	// A backend that is both the display and the keypad behind different wrappers can be called more than once for the same frame
+	BeginFrame(frame uint64)
+}
+
+// AnimatedIO ... Implemented by displays that keep changing after a frame has been presented, such as pixels that fade out over several frames
+type AnimatedIO interface {
+	Display
+
+	// Animating ... Returns true while the last frame presented needs presenting again for the display to finish changing
+	Animating() bool
+}
+
+// StatsIO ... Implemented by displays that can show how fast the emulator is running alongside the frame
+type StatsIO interface {
+	Display
+
+	// ShowStats ... Sets the frames presented and instructions executed per second to show with the next frame presented
+	ShowStats(fps, ips float64)
+}
@@ -0,0 +1,137 @@
+package io
+
+import (
+	"sync"
+	"time"
+)
+
+// NoKey ... Reported by keypads when no key is pressed
+const NoKey byte = 0xFF
+
+// KeyHold ... How long terminal backends count a key as held down after it was last reported. Terminals only report presses and key repeats rather than releases,
+// so a key is held for as long as its repeats keep arriving, and keyboards commonly wait 500ms before they start repeating. The cost is that a single tap
+// is held for the whole of KeyHold too, about 36 frames at 60Hz
+const KeyHold = 600 * time.Millisecond
+
+// waitGap ... How long ListenWait can go uncalled before the next call is taken as the start of a new Fx0A wait, which only takes keys pressed after it
+const waitGap = 100 * time.Millisecond
+
+// KeyState ... The state of the hex keypad, for backends that are told when keys are pressed and released. Implements KeyStateIO, so backends can answer
+// the chip's key queries from it. Safe for concurrent use, since keys are pressed by a backend's own goroutine while the chip reads them. Should be instantiated using io.NewKeyState()
+// hold is how long a key stays down after it was last pressed, KeyHold for terminals. 0 keeps keys down until released
+// mu guards everything below it. down holds whether each key is down, and seen when it was last pressed. lastKey is the last key pressed,
+// pressed the key ListenWait will take next, and lastWait when ListenWait was last called
+// framed is set by the first call of BeginFrame. frame is the frame being emulated, pressedFrame one more than the frame pressed was pressed on (0 if before the first frame), and waitFrame the frame after the one ListenWait was last called on (0 before its first call)
+type KeyState struct {
+	hold time.Duration
+
+	mu       sync.Mutex
+	down     [16]bool
+	seen     [16]time.Time
+	lastKey  byte
+	pressed  byte
+	lastWait time.Time
+
+	framed       bool
+	frame        uint64
+	pressedFrame uint64
+	waitFrame    uint64
+}
+
+// NewKeyState ... Creates a KeyState with no keys down, where a pressed key stays down for hold, or until released if hold is 0
+func NewKeyState(hold time.Duration) *KeyState {
+	return &KeyState{hold: hold, lastKey: NoKey, pressed: NoKey}
+}
+
+// isDown ... Returns true if key is down. mu must be held
+func (k *KeyState) isDown(key byte) bool {
+	return key < byte(len(k.down)) && k.down[key] && (k.hold == 0 || time.Since(k.seen[key]) < k.hold)
+}
+
+// Press ... Marks a hex key as down. A key that wasnt already down becomes the key ListenWait takes next. Keys above 0xF are ignored
+func (k *KeyState) Press(key byte) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if key >= byte(len(k.down)) {
+		return
+	}
+	if !k.isDown(key) {
+		k.pressed, k.pressedFrame = key, 0
+		if k.framed {
+			k.pressedFrame = k.frame + 1
+		}
+	}
+	k.down[key] = true
+	k.seen[key] = time.Now()
+	k.lastKey = key
+}
+
+// Release ... Marks a hex key as up. Keys above 0xF are ignored
+func (k *KeyState) Release(key byte) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if key < byte(len(k.down)) {
+		k.down[key] = false
+	}
+}
+
+// ReleaseAll ... Marks every key as up, for when the keyboard is lost before its keys are released
+func (k *KeyState) ReleaseAll() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.down = [16]bool{}
+}
+
+// BeginFrame ... Tells the KeyState which frame is being emulated, for backends whose keys are driven frame by frame rather than in real time.
+// Once it has been called, ListenWait tells waits apart by frame instead of by the wall clock, so runs that go faster or slower than real time behave the same
+func (k *KeyState) BeginFrame(frame uint64) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.framed = true
+	k.frame = frame
+}
+
+// ListenWait ... Returns the key pressed since the last call, or NoKey if there isnt one. Fx0A repeats until it gets a key, so never blocks.
+// Keys pressed before the first call of a wait dont count, so a wait is only ended by a fresh press. Under BeginFrame, a wait starts on the
+// first frame ListenWait is called after a frame without a call, and keys pressed at the start of that frame count
+func (k *KeyState) ListenWait() (byte, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.framed {
+		if (k.waitFrame == 0 || k.frame > k.waitFrame) && k.pressedFrame <= k.frame {
+			k.pressed = NoKey
+		}
+		k.waitFrame = k.frame + 1
+	} else {
+		now := time.Now()
+		if now.Sub(k.lastWait) > waitGap {
+			k.pressed = NoKey
+		}
+		k.lastWait = now
+	}
+	key := k.pressed
+	k.pressed = NoKey
+	return key, nil
+}
+
+// ListenNow ... Returns the last key pressed if it is still down, or any other key that is. NoKey if none are
+func (k *KeyState) ListenNow() (byte, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.isDown(k.lastKey) {
+		return k.lastKey, nil
+	}
+	for key := range k.down {
+		if k.isDown(byte(key)) {
+			return byte(key), nil
+		}
+	}
+	return NoKey, nil
+}
+
+// KeyDown ... Returns true if the given hex key is down
+func (k *KeyState) KeyDown(key byte) bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.isDown(key)
+}
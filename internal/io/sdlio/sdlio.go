@@ -1,111 +1,466 @@
+//go:build sdl
+
 package sdlio
 
-// NOTE: This package requires that libsdl2 is installed
+// NOTE: This package requires that libsdl2 is installed, and is only built with -tags sdl
+// SDL is only called from the main OS thread, so programs using it must run inside sdl.Main. It runs headless under SDL's dummy drivers: set SDL_VIDEODRIVER=dummy and SDL_AUDIODRIVER=dummy
 import (
+	"encoding/binary"
 	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
 
+	"github.com/TH3-F001/GoChip-8/chip8/internal/framebuffer"
+	"github.com/TH3-F001/GoChip-8/chip8/internal/io"
+	"github.com/TH3-F001/GoChip-8/chip8/internal/palette"
 	"github.com/veandco/go-sdl2/sdl"
 )
 
+// defaultScale ... How many times each pixel is repeated across and down in a new window when no scale is configured
+const defaultScale = 10
+
+// pollInterval ... The longest the event loop waits for an event, in milliseconds, before checking on the beep and the next frame
+const pollInterval = 10
+
+// Audio settings ... The beep is queued as 8-bit samples, audioChunk of them at a time. Chunks are kept short so that a new audio pattern is heard quickly
+const (
+	sampleRate = 44100
+	audioChunk = sampleRate / 20
+	beepVolume = 24
+)
+
+// SdlIO ... Holds state for the SDL window. Should be instantiated using sdlio.New()
+// SDL is only ever called by the event loop, which runs each pass on the main OS thread with sdl.Do. The other methods hand it work through mu and wake it with wake()
+// palette holds the ARGB colour of each combination of lit bitplanes (none, first, second, both)
+// scale is how many times each pixel is repeated across and down. 0 fits the window
+// window, renderer and texture are the SDL window, its renderer, and the streaming texture frames are copied to. texture is sized to the last frame
+// audio is the audio device the beep is queued on, or 0 if there is no audio device. playing is true while the beep is queued, and phase is how far through the pattern it has got
+// redraw is true when the window needs drawing again, even without a new frame. keys holds whether each hex key is held down
+// mu guards everything below it. frame is a copy of the last frame presented, and dirty is true until it has been copied to the texture
+// pattern and rate are the audio pattern the beep plays and its rate in Hz, and newPattern is true until the event loop has started playing them
+// hotkeyCh and termCh are where hotkeys and termination requests are sent. nil until SetHotkeyChannel and ListenForControl are called
+// sound is true while the chip8 wants the beep playing. quit is closed by Terminate, and done by the event loop once it has cleaned up
 type SdlIO struct {
-	Pixels [][]byte
-	fg     uint32
-	bg     uint32
-	window *sdl.Window
+	width   int
+	height  int
+	scale   int
+	palette [4]uint32
+
+	window   *sdl.Window
+	renderer *sdl.Renderer
+	texture  *sdl.Texture
+	texW     int
+	texH     int
+	audio    sdl.AudioDeviceID
+	playing  bool
+	phase    float64
+	redraw   bool
+	keys     *io.KeyState
+
+	mu         sync.Mutex
+	frame      *framebuffer.Framebuffer
+	dirty      bool
+	pattern    [16]byte
+	rate       float64
+	newPattern bool
+	hotkeyCh   chan<- io.Hotkey
+	termCh     chan<- bool
+
+	sound    atomic.Bool
+	quit     chan struct{}
+	quitOnce sync.Once
+	done     chan struct{}
+}
+
+// keyMap ... Maps the physical keys of the left of a QWERTY keyboard to the COSMAC VIP's hex keypad. Scancodes are used so the layout is the same on every keyboard
+var keyMap map[sdl.Scancode]byte = map[sdl.Scancode]byte{
+	sdl.SCANCODE_1: 0x1, sdl.SCANCODE_2: 0x2, sdl.SCANCODE_3: 0x3, sdl.SCANCODE_4: 0xC,
+	sdl.SCANCODE_Q: 0x4, sdl.SCANCODE_W: 0x5, sdl.SCANCODE_E: 0x6, sdl.SCANCODE_R: 0xD,
+	sdl.SCANCODE_A: 0x7, sdl.SCANCODE_S: 0x8, sdl.SCANCODE_D: 0x9, sdl.SCANCODE_F: 0xE,
+	sdl.SCANCODE_Z: 0xA, sdl.SCANCODE_X: 0x0, sdl.SCANCODE_C: 0xB, sdl.SCANCODE_V: 0xF,
+}
+
+// hotkeyMap ... Maps function keys to the hotkeys they trigger. The same keys as tcellio
+var hotkeyMap map[sdl.Scancode]io.Hotkey = map[sdl.Scancode]io.Hotkey{
+	sdl.SCANCODE_F5:        io.HotkeySaveState,
+	sdl.SCANCODE_F6:        io.HotkeyPrevSlot,
+	sdl.SCANCODE_F7:        io.HotkeyNextSlot,
+	sdl.SCANCODE_F8:        io.HotkeyLoadState,
+	sdl.SCANCODE_F9:        io.HotkeyRecord,
+	sdl.SCANCODE_F12:       io.HotkeyScreenshot,
+	sdl.SCANCODE_BACKSPACE: io.HotkeyRewind,
+}
+
+// New ... Opens a window sized for a width by height display, and an audio device for the beep. Starts the event loop
+// fg and bg expect colors as hexcodes. custom optionally overrides the colours of each combination of lit XO-CHIP bitplanes. see palette.Resolve
+// scale is how many times each pixel is repeated across and down, or 0 to fit the window as it is resized
+// returns an error if scale is negative, or if SDL or the window cant be initialized. A missing audio device only leaves the beep silent. Must be called inside sdl.Main
+func New(width, height int, fgColor, bgColor uint32, custom []uint32, scale int) (*SdlIO, error) {
+	if scale < 0 {
+		return nil, fmt.Errorf("error in sdlio/New(): invalid scale %d", scale)
+	}
+	colors := palette.Resolve(fgColor, bgColor, custom)
+	for i := range colors {
+		colors[i] |= 0xFF000000
+	}
+
+	// the window starts out blank until the first frame is presented
+	blank, err := framebuffer.New(height, width)
+	if err != nil {
+		return nil, fmt.Errorf("error in sdlio/New(): %w", err)
+	}
+	s := SdlIO{
+		width:   width,
+		height:  height,
+		scale:   scale,
+		palette: colors,
+		frame:   blank,
+		dirty:   true,
+		keys:    io.NewKeyState(0),
+		pattern: io.BeepPattern,
+		rate:    io.BeepRate,
+		quit:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	sdl.Do(func() {
+		if err = s.init(); err != nil {
+			s.destroy()
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error in sdlio/New(): %w", err)
+	}
+	go s.loop()
+	return &s, nil
+}
+
+// #region Event loop
+
+// loop ... Handles events, plays the beep, and draws frames on the main thread until Terminate is called, then shuts SDL down
+func (s *SdlIO) loop() {
+	defer close(s.done)
+	for quit := false; !quit; {
+		sdl.Do(func() { quit = s.pass() })
+	}
 }
 
-func New(width, height int, fgColor, bgColor uint32) (*SdlIO, error) {
-	result := SdlIO{}
-	if err := sdl.Init(uint32(sdl.INIT_EVERYTHING)); err != nil {
-		return &result, err
+// pass ... Handles the events that arrive within pollInterval, then brings the beep and the window up to date. Shuts SDL down and returns true once Terminate has been called
+func (s *SdlIO) pass() bool {
+	for event := sdl.WaitEventTimeout(pollInterval); event != nil; event = sdl.PollEvent() {
+		s.handle(event)
 	}
+	select {
+	case <-s.quit:
+		s.destroy()
+		return true
+	default:
+	}
+	s.updateSound()
+	// drawing errors are dropped, since there is no one on the main thread to return them to. the next frame tries again
+	if s.upload() || s.redraw {
+		s.draw()
+	}
+	return false
+}
+
+// init ... Creates the window, renderer and texture, and opens the audio device
+func (s *SdlIO) init() error {
+	if err := sdl.Init(sdl.INIT_VIDEO); err != nil {
+		return err
+	}
+	scale := s.scale
+	if scale == 0 {
+		scale = defaultScale
+	}
+	window, err := sdl.CreateWindow("GoChip-8", sdl.WINDOWPOS_CENTERED, sdl.WINDOWPOS_CENTERED,
+		int32(s.width*scale), int32(s.height*scale), sdl.WINDOW_SHOWN|sdl.WINDOW_RESIZABLE|sdl.WINDOW_ALLOW_HIGHDPI)
+	if err != nil {
+		return err
+	}
+	s.window = window
+	s.window.SetMinimumSize(int32(s.width), int32(s.height))
 
-	win, err := sdl.CreateWindow("Keyboard Listener", int32(sdl.WINDOWPOS_UNDEFINED), int32(sdl.WINDOWPOS_UNDEFINED), 800, 600, uint32(sdl.WINDOW_SHOWN))
+	if s.renderer, err = sdl.CreateRenderer(window, -1, 0); err != nil {
+		return err
+	}
+	if err := s.resizeTexture(s.width, s.height); err != nil {
+		return err
+	}
+	s.redraw = true
+	s.openAudio()
+	return nil
+}
+
+// openAudio ... Opens the default audio device for the beep. Leaves audio at 0 if there isnt one
+func (s *SdlIO) openAudio() {
+	if err := sdl.InitSubSystem(sdl.INIT_AUDIO); err != nil {
+		return
+	}
+	spec := sdl.AudioSpec{Freq: sampleRate, Format: sdl.AUDIO_U8, Channels: 1, Samples: 1024}
+	audio, err := sdl.OpenAudioDevice("", false, &spec, nil, 0)
 	if err != nil {
-		return &result, err
+		return
 	}
+	s.audio = audio
+}
+
+// destroy ... Closes the audio device, destroys whatever was created of the window, and shuts SDL down
+func (s *SdlIO) destroy() {
+	if s.audio != 0 {
+		sdl.CloseAudioDevice(s.audio)
+	}
+	if s.texture != nil {
+		s.texture.Destroy()
+	}
+	if s.renderer != nil {
+		s.renderer.Destroy()
+	}
+	if s.window != nil {
+		s.window.Destroy()
+	}
+	sdl.Quit()
+}
+
+// handle ... Acts on a single SDL event
+func (s *SdlIO) handle(event sdl.Event) {
+	switch event := event.(type) {
+	case *sdl.QuitEvent:
+		s.mu.Lock()
+		s.requestTermination()
+		s.mu.Unlock()
+	case *sdl.WindowEvent:
+		if event.Event == sdl.WINDOWEVENT_SIZE_CHANGED || event.Event == sdl.WINDOWEVENT_EXPOSED {
+			s.redraw = true
+		}
+	case *sdl.KeyboardEvent:
+		s.handleKey(event)
+	}
+}
+
+// handleKey ... Tracks the hex keys held down, and sends hotkeys and termination requests. Only the rewind hotkey is sent again as its key repeats
+func (s *SdlIO) handleKey(event *sdl.KeyboardEvent) {
+	down := event.Type == sdl.KEYDOWN
+	code := event.Keysym.Scancode
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	result.window = win
-	return &result, nil
+	if code == sdl.SCANCODE_ESCAPE && down {
+		s.requestTermination()
+		return
+	}
+	if hotkey, ok := hotkeyMap[code]; ok && down && s.hotkeyCh != nil && (event.Repeat == 0 || hotkey == io.HotkeyRewind) {
+		select {
+		case s.hotkeyCh <- hotkey:
+		default:
+		}
+	}
+	if key, ok := keyMap[code]; ok {
+		if down {
+			s.keys.Press(key)
+		} else {
+			s.keys.Release(key)
+		}
+	}
 }
 
-func (io SdlIO) GetPixels() *[][]byte {
-	return &io.Pixels
+// requestTermination ... Asks the program to terminate, if ListenForControl has been called. Sent on a goroutine of its own so that the event loop
+// keeps running even if no one is listening anymore. mu must be held
+func (s *SdlIO) requestTermination() {
+	if termCh := s.termCh; termCh != nil {
+		go func() { termCh <- true }()
+	}
 }
 
-func (io SdlIO) GetPixel(col, row byte) byte {
-	return io.Pixels[row][col]
+// wake ... Wakes the event loop up from waiting for events. SDL_PushEvent is thread safe, so this is the one SDL call made off the main thread
+func (s *SdlIO) wake() {
+	sdl.PushEvent(&sdl.UserEvent{Type: sdl.USEREVENT})
 }
 
-func (*SdlIO) SetPixel(row, col byte, lit byte) error {
+//#endregion
+
+// #region Display
+
+// Present ... Copies a completed frame for the event loop to draw. error is only to conform to the IO interface and isnt used
+func (s *SdlIO) Present(fb *framebuffer.Framebuffer) error {
+	s.mu.Lock()
+	if s.frame != nil && s.frame.Width() == fb.Width() && s.frame.Height() == fb.Height() {
+		s.frame.CopyFrom(fb)
+	} else {
+		s.frame = fb.Clone()
+	}
+	s.dirty = true
+	s.mu.Unlock()
+	s.wake()
 	return nil
 }
 
-func (*SdlIO) Refresh() error {
+// resizeTexture ... Replaces the texture with a width by height one
+func (s *SdlIO) resizeTexture(width, height int) error {
+	if s.texture != nil {
+		s.texture.Destroy()
+		s.texture = nil
+	}
+	texture, err := s.renderer.CreateTexture(sdl.PIXELFORMAT_ARGB8888, sdl.TEXTUREACCESS_STREAMING, int32(width), int32(height))
+	if err != nil {
+		return err
+	}
+	s.texture, s.texW, s.texH = texture, width, height
 	return nil
 }
 
-func (*SdlIO) Listen() (byte, error) {
-	active := true
-	var result byte = 255
-	var event sdl.Event
-	for active {
-		fmt.Println("doom")
-		for event = sdl.WaitEvent(); event != nil; event = sdl.PollEvent() {
-			fmt.Println(event)
-			if keyboardEvent, ok := event.(*sdl.KeyboardEvent); ok {
-
-				switch int(keyboardEvent.Keysym.Scancode) {
-
-				case 0x82:
-					result = 0x01
-				case 0x83:
-					result = 0x02
-				case 0x84:
-					result = 0x03
-				case 0x85:
-					result = 0x0C
-				case 0x90:
-					result = 0x04
-				case 0x91:
-					result = 0x05
-				case 0x92:
-					result = 0x06
-				case 0x93:
-					result = 0x0D
-				case 0x9e:
-					result = 0x07
-				case 0x9f:
-					result = 0x08
-				case 0xa0:
-					result = 0x09
-				case 0xa1:
-					result = 0x0E
-				case 0xac:
-					result = 0x0A
-				case 0xad:
-					result = 0x00
-				case 0xae:
-					result = 0x0B
-				case 0xaf:
-					result = 0x0F
-
-				default:
-					continue
-				}
-				if result != 255 {
-					return result, nil
-				}
-			} else {
-				fmt.Println("not okay")
-			}
+// upload ... Copies the last frame presented to the texture, if it hasnt been already.
+// returns true if the texture has changed
+func (s *SdlIO) upload() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.dirty {
+		return false
+	}
+	s.dirty = false
+	fb := s.frame
+	if fb.Width() != s.texW || fb.Height() != s.texH {
+		if err := s.resizeTexture(fb.Width(), fb.Height()); err != nil {
+			return false
 		}
 	}
-	return result, nil
+	pixels, pitch, err := s.texture.Lock(nil)
+	if err != nil {
+		return false
+	}
+	defer s.texture.Unlock()
+	for row := 0; row < fb.Height(); row++ {
+		line := pixels[row*pitch:]
+		for col := 0; col < fb.Width(); col++ {
+			binary.NativeEndian.PutUint32(line[col*4:], s.palette[fb.Pixel(row, col)])
+		}
+	}
+	return true
 }
 
-func (io *SdlIO) Terminate() error {
-	io.window.Destroy()
-	sdl.Quit()
-	return nil
+// draw ... Draws the texture centered in the window, with every pixel repeated scale times across and down, or as many times as fit
+func (s *SdlIO) draw() {
+	s.redraw = false
+	w, h, err := s.renderer.GetOutputSize()
+	if err != nil {
+		return
+	}
+	scale := int32(s.scale)
+	if scale == 0 {
+		scale = max(min(w/int32(s.texW), h/int32(s.texH)), 1)
+	}
+	dst := sdl.Rect{W: int32(s.texW) * scale, H: int32(s.texH) * scale}
+	dst.X, dst.Y = (w-dst.W)/2, (h-dst.H)/2
+
+	bg := s.palette[0]
+	s.renderer.SetDrawColor(uint8(bg>>16), uint8(bg>>8), uint8(bg), 0xFF)
+	s.renderer.Clear()
+	s.renderer.Copy(s.texture, nil, &dst)
+	s.renderer.Present()
+}
+
+//#endregion
+
+// #region Sound
+
+// Sound ... Starts or stops the beep
+func (s *SdlIO) Sound(on bool) {
+	s.sound.Store(on)
+	s.wake()
+}
+
+// SetPattern ... Sets the XO-CHIP audio pattern the beep plays, and the rate in Hz its bits are played at. A pattern of all zeros goes back to the plain beep
+func (s *SdlIO) SetPattern(pattern [16]byte, rate float64) {
+	if pattern == [16]byte{} {
+		pattern, rate = io.BeepPattern, io.BeepRate
+	}
+	s.mu.Lock()
+	if pattern != s.pattern || rate != s.rate {
+		s.pattern, s.rate, s.newPattern = pattern, rate, true
+	}
+	s.mu.Unlock()
+	s.wake()
+}
+
+// updateSound ... Starts or stops the beep to match Sound, and keeps at least a chunk of it queued while it plays. A new pattern replaces whatever is queued of the last one
+func (s *SdlIO) updateSound() {
+	if s.audio == 0 {
+		return
+	}
+	on := s.sound.Load()
+	s.mu.Lock()
+	pattern, rate, changed := s.pattern, s.rate, s.newPattern
+	s.newPattern = false
+	s.mu.Unlock()
+	switch {
+	case on && (!s.playing || changed):
+		sdl.ClearQueuedAudio(s.audio)
+		sdl.QueueAudio(s.audio, s.samples(pattern, rate, 2*audioChunk))
+		sdl.PauseAudioDevice(s.audio, false)
+	case !on && s.playing:
+		sdl.PauseAudioDevice(s.audio, true)
+		sdl.ClearQueuedAudio(s.audio)
+	case on && sdl.GetQueuedAudioSize(s.audio) < audioChunk:
+		sdl.QueueAudio(s.audio, s.samples(pattern, rate, audioChunk))
+	}
+	s.playing = on
+}
+
+// samples ... Returns the next n samples of the pattern looped at rate bits per second, carrying on from where the last samples left off
+func (s *SdlIO) samples(pattern [16]byte, rate float64, n int) []byte {
+	buf := make([]byte, n)
+	step := rate / sampleRate
+	for i := range buf {
+		bit := int(s.phase)
+		if pattern[bit/8]&(0x80>>(bit%8)) != 0 {
+			buf[i] = 128 + beepVolume
+		} else {
+			buf[i] = 128 - beepVolume
+		}
+		s.phase = math.Mod(s.phase+step, float64(8*len(pattern)))
+	}
+	return buf
+}
+
+//#endregion
+
+// #region Input
+
+// ListenWait ... Returns the key pressed since the last call, or io.NoKey if there isnt one. Fx0A repeats until it gets a key, so never blocks.
+// Keys pressed before the first call of a wait dont count, so a wait is only ended by a fresh press
+func (s *SdlIO) ListenWait() (byte, error) {
+	return s.keys.ListenWait()
+}
+
+// ListenNow ... Returns the last key pressed if it is still held down, or any other key that is. io.NoKey if none are
+func (s *SdlIO) ListenNow() (byte, error) {
+	return s.keys.ListenNow()
+}
+
+// KeyDown ... Returns true if the given hex key is currently held down
+func (s *SdlIO) KeyDown(key byte) bool {
+	return s.keys.KeyDown(key)
+}
+
+// SetHotkeyChannel ... Sets the channel that hotkeys (F5: save state, F6/F7: previous/next slot, F8: load state, F9: start/stop recording, F12: screenshot, Backspace: rewind) are sent to
+func (s *SdlIO) SetHotkeyChannel(hotkeyCh chan<- io.Hotkey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hotkeyCh = hotkeyCh
+}
+
+// ListenForControl ... Sends to termCh when the window is closed or Escape is pressed. Events are already handled by the event loop, so doesnt start a goroutine of its own
+func (s *SdlIO) ListenForControl(termCh chan<- bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.termCh = termCh
+}
+
+//#endregion
+
+// Terminate ... Stops the event loop and destroys the window. Returns once SDL has shut down, leaving the program to exit by returning from sdl.Main
+func (s *SdlIO) Terminate() {
+	s.quitOnce.Do(func() { close(s.quit) })
+	s.wake()
+	<-s.done
 }
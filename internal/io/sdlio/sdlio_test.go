@@ -0,0 +1,158 @@
+//go:build sdl
+
+package sdlio
+
+import (
+	"encoding/binary"
+	"os"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/TH3-F001/GoChip-8/chip8/internal/framebuffer"
+	"github.com/TH3-F001/GoChip-8/chip8/internal/io"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// TestMain ... Runs the tests inside sdl.Main with SDL's dummy drivers, so they need neither a display nor a sound card
+func TestMain(m *testing.M) {
+	os.Setenv("SDL_VIDEODRIVER", "dummy")
+	os.Setenv("SDL_AUDIODRIVER", "dummy")
+	code := 0
+	sdl.Main(func() { code = m.Run() })
+	os.Exit(code)
+}
+
+// newDummy ... Creates a 64x32 SdlIO at scale 1, terminated when the test ends
+func newDummy(t *testing.T) *SdlIO {
+	t.Helper()
+	s, err := New(64, 32, 0xFFFFFF, 0x000000, nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(s.Terminate)
+	return s
+}
+
+// waitFor ... Fails the test if cond doesnt become true within a second
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); !cond(); time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+	}
+}
+
+func TestPresentDrawsTheFrame(t *testing.T) {
+	s := newDummy(t)
+	fb, err := framebuffer.New(32, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fb.Set(0, 0, 0, true)
+	if err := s.Present(fb); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "the frame to be uploaded", func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return !s.dirty
+	})
+
+	// the frame is uploaded and drawn in the same pass, so reading the window on the main thread afterwards sees it
+	var lit, unlit uint32
+	sdl.Do(func() {
+		pixels := make([]byte, 8)
+		if err = s.renderer.ReadPixels(&sdl.Rect{W: 2, H: 1}, sdl.PIXELFORMAT_ARGB8888, unsafe.Pointer(&pixels[0]), len(pixels)); err == nil {
+			lit, unlit = binary.NativeEndian.Uint32(pixels), binary.NativeEndian.Uint32(pixels[4:])
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lit&0xFFFFFF != 0xFFFFFF || unlit&0xFFFFFF != 0x000000 {
+		t.Errorf("window pixels = %#08x, %#08x, want the foreground then the background", lit, unlit)
+	}
+
+	// a bigger frame, such as after switching to high resolution, gets a texture of its own
+	big, err := framebuffer.New(64, 128)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Present(big)
+	waitFor(t, "the texture to be resized", func() bool {
+		var w, h int
+		sdl.Do(func() { w, h = s.texW, s.texH })
+		return w == 128 && h == 64
+	})
+}
+
+func TestKeyDownFollowsKeyboardEvents(t *testing.T) {
+	s := newDummy(t)
+	press := func(state uint32, code sdl.Scancode) {
+		sdl.PushEvent(&sdl.KeyboardEvent{Type: state, Keysym: sdl.Keysym{Scancode: code}})
+	}
+
+	press(sdl.KEYDOWN, sdl.SCANCODE_W)
+	waitFor(t, "key 5 to be held", func() bool { return s.KeyDown(0x5) })
+	if key, _ := s.ListenNow(); key != 0x5 {
+		t.Errorf("ListenNow() = %#x, want 0x5", key)
+	}
+	if s.KeyDown(0x4) {
+		t.Error("KeyDown(0x4) = true, want only 0x5 held")
+	}
+
+	// SDL reports releases, so a key stays held however long it is down, and comes up as soon as it is released
+	time.Sleep(50 * time.Millisecond)
+	if !s.KeyDown(0x5) {
+		t.Error("KeyDown(0x5) = false while the key is still held")
+	}
+	press(sdl.KEYUP, sdl.SCANCODE_W)
+	waitFor(t, "key 5 to be released", func() bool { return !s.KeyDown(0x5) })
+	if key, _ := s.ListenNow(); key != io.NoKey {
+		t.Errorf("ListenNow() = %#x with no key held", key)
+	}
+}
+
+func TestSoundQueuesTheBeep(t *testing.T) {
+	s := newDummy(t)
+	var audio sdl.AudioDeviceID
+	sdl.Do(func() { audio = s.audio })
+	if audio == 0 {
+		t.Skip("no audio device")
+	}
+	queued := func() uint32 {
+		var size uint32
+		sdl.Do(func() { size = sdl.GetQueuedAudioSize(audio) })
+		return size
+	}
+
+	s.Sound(true)
+	waitFor(t, "the beep to be queued", func() bool { return queued() > 0 })
+
+	s.SetPattern([16]byte{0xFF, 0x00}, 8000)
+	waitFor(t, "the pattern to be played", func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return !s.newPattern
+	})
+	s.mu.Lock()
+	pattern, rate := s.pattern, s.rate
+	s.mu.Unlock()
+	if pattern != [16]byte{0xFF, 0x00} || rate != 8000 {
+		t.Errorf("playing pattern % x at %v Hz, want ff 00... at 8000 Hz", pattern, rate)
+	}
+
+	// a silent pattern goes back to the plain beep rather than playing nothing
+	s.SetPattern([16]byte{}, 4000)
+	s.mu.Lock()
+	pattern, rate = s.pattern, s.rate
+	s.mu.Unlock()
+	if pattern != io.BeepPattern || rate != io.BeepRate {
+		t.Errorf("an all zero pattern gave % x at %v Hz, want the beep", pattern, rate)
+	}
+
+	s.Sound(false)
+	waitFor(t, "the beep to stop", func() bool { return queued() == 0 })
+}
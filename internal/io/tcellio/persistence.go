@@ -0,0 +1,117 @@
+package tcellio
+
+import (
+	"fmt"
+
+	"github.com/TH3-F001/GoChip-8/chip8/internal/framebuffer"
+	"github.com/gdamore/tcell/v2"
+)
+
+// maxPersistence ... The most frames a pixel can take to fade out
+const maxPersistence = 254
+
+// fadeGlyphs ... The characters used to shade fading pixels on terminals without truecolor, from faintest to fully lit
+var fadeGlyphs = []rune{'░', '▒', '▓'}
+
+// phosphor ... How a pixel glows: the bitplanes it was last lit on, and how brightly as a fraction of fully lit
+type phosphor struct {
+	mask byte
+	glow float64
+}
+
+// fade ... The phosphor persistence filter. Pixels that turn off fade out over frames frames instead of disappearing at once, so sprites that are
+// erased and redrawn every frame stop flickering
+// levels holds how brightly each pixel glows: lit pixels are frames+1, and fading pixels count down to 0 by one every frame presented.
+// masks holds the bitplanes each pixel was last lit on, so that it fades from the right colour
+// fading is the number of pixels currently fading out
+type fade struct {
+	frames int
+	width  int
+	height int
+	levels []byte
+	masks  []byte
+	fading int
+}
+
+// checkPersistence ... Returns an error if frames is too long a fade
+func checkPersistence(frames int) error {
+	if frames < 0 || frames > maxPersistence {
+		return fmt.Errorf("invalid persistence %d. must be between 0 and %d frames", frames, maxPersistence)
+	}
+	return nil
+}
+
+// advance ... Lights every pixel lit in fb, and fades the rest by a frame
+func (f *fade) advance(fb *framebuffer.Framebuffer) {
+	if f.width != fb.Width() || f.height != fb.Height() {
+		f.width, f.height = fb.Width(), fb.Height()
+		f.levels = make([]byte, f.width*f.height)
+		f.masks = make([]byte, f.width*f.height)
+	}
+	f.fading = 0
+	for row := 0; row < f.height; row++ {
+		for col := 0; col < f.width; col++ {
+			i := row*f.width + col
+			if mask := fb.Pixel(row, col); mask != 0 {
+				f.levels[i], f.masks[i] = byte(f.frames+1), mask
+			} else if f.levels[i] > 0 {
+				f.levels[i]--
+				if f.levels[i] > 0 {
+					f.fading++
+				}
+			}
+		}
+	}
+}
+
+// at ... Returns how the pixel at the given row and column glows
+func (f *fade) at(row, col int) phosphor {
+	i := row*f.width + col
+	if f.levels[i] == 0 {
+		return phosphor{}
+	}
+	return phosphor{f.masks[i], float64(f.levels[i]) / float64(f.frames+1)}
+}
+
+// Animating ... Returns true while any pixel is still fading out, so the last frame needs presenting again for the fade to carry on
+func (io *TcellIO) Animating() bool {
+	io.state.mu.Lock()
+	defer io.state.mu.Unlock()
+	return io.state.fade != nil && io.state.fade.fading > 0
+}
+
+// mix ... Returns the colour a fraction glow of the way from the background colour to palette entry mask
+func (io TcellIO) mix(mask byte, glow float64) tcell.Color {
+	from, to := io.rgb[0], io.rgb[mask]
+	channel := func(shift uint) int32 {
+		a, b := float64(from>>shift&0xFF), float64(to>>shift&0xFF)
+		return int32(a+(b-a)*glow+0.5) << shift
+	}
+	return tcell.NewHexColor(channel(16) | channel(8) | channel(0))
+}
+
+// shade ... Returns the colour to draw a pixel with. Without truecolor, pixels stay lit for the first half of their fade instead
+func (io TcellIO) shade(p phosphor, truecolor bool) tcell.Color {
+	switch {
+	case p.glow >= 1 || p.mask == 0:
+		return io.colors[p.mask]
+	case truecolor:
+		return io.mix(p.mask, p.glow)
+	case p.glow >= 0.5:
+		return io.colors[p.mask]
+	}
+	return io.colors[0]
+}
+
+// glyph ... Returns the character and style to draw a pixel that fills whole cells with. Without truecolor, fading pixels are shaded with fadeGlyphs instead of colour
+func (io TcellIO) glyph(p phosphor, truecolor bool) (rune, tcell.Style) {
+	switch {
+	case p.mask == 0:
+		return charMap[false], io.palette[0]
+	case p.glow >= 1 && (truecolor || io.state.fade == nil):
+		return charMap[true], io.palette[p.mask]
+	case truecolor:
+		return charMap[true], io.style.Foreground(io.mix(p.mask, p.glow))
+	}
+	return fadeGlyphs[min(int(p.glow*float64(len(fadeGlyphs))), len(fadeGlyphs)-1)], io.palette[p.mask]
+}
@@ -0,0 +1,205 @@
+package tcellio
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/TH3-F001/GoChip-8/chip8/internal/framebuffer"
+	"github.com/gdamore/tcell/v2"
+)
+
+// Renderers ... The ways a frame can be drawn with terminal cells. Terminal cells are about twice as tall as they are wide
+const (
+	// RendererAuto ... Picks whichever square pixel renderer and scale draws the largest image that fits the terminal, every time it is resized
+	RendererAuto = "auto"
+	// RendererBlock ... One cell per pixel. Pixels are twice as tall as they are wide
+	RendererBlock = "block"
+	// RendererDouble ... Two cells side by side per pixel, so pixels are square
+	RendererDouble = "double"
+	// RendererHalfBlock ... Two pixels stacked in every cell using the upper half block character, so pixels are square
+	RendererHalfBlock = "halfblock"
+	// RendererBraille ... A 2x4 grid of pixels in every cell using Braille patterns. Pixels are square, but every cell only has a single colour
+	RendererBraille = "braille"
+)
+
+// autoRenderers ... The renderers RendererAuto picks from, in order of preference when two draw pixels the same size
+var autoRenderers = []string{RendererHalfBlock, RendererDouble, RendererBraille}
+
+// brailleDots ... The bit of a Braille pattern for each dot in its 2 column, 4 row grid, indexed by [row][col]
+var brailleDots = [4][2]rune{
+	{0x01, 0x08},
+	{0x02, 0x10},
+	{0x04, 0x20},
+	{0x40, 0x80},
+}
+
+// brailleBlank ... The Braille pattern with no dots raised. Every other pattern is brailleBlank plus its dots
+const brailleBlank rune = 0x2800
+
+// layout ... A renderer and the number of times each pixel is repeated across and down
+type layout struct {
+	renderer string
+	scale    int
+}
+
+// renderState ... The parts of a TcellIO shared between every copy of it, since the input methods have value receivers but need to redraw the screen on resize.
+// mu guards everything else. last is a copy of the last frame presented, redrawn when the terminal is resized
+// current is the layout last drawn with, and stats the line drawn below the display
+// fade is the phosphor persistence filter. nil when disabled
+type renderState struct {
+	mu      sync.Mutex
+	last    *framebuffer.Framebuffer
+	current layout
+	stats   string
+	fade    *fade
+}
+
+// checkRenderer ... Returns an error if renderer isnt one of the renderers above, or scale is negative
+func checkRenderer(renderer string, scale int) error {
+	switch renderer {
+	case RendererAuto, RendererBlock, RendererDouble, RendererHalfBlock, RendererBraille:
+	default:
+		return fmt.Errorf("unknown renderer %q", renderer)
+	}
+	if scale < 0 {
+		return fmt.Errorf("invalid scale %d", scale)
+	}
+	return nil
+}
+
+// cells ... Returns the number of terminal columns and rows needed to draw a width by height frame with the given layout
+func (l layout) cells(width, height int) (int, int) {
+	w, h := width*l.scale, height*l.scale
+	switch l.renderer {
+	case RendererDouble:
+		return 2 * w, h
+	case RendererHalfBlock:
+		return w, (h + 1) / 2
+	case RendererBraille:
+		return (w + 1) / 2, (h + 3) / 4
+	}
+	return w, h
+}
+
+// pixelWidth ... Returns how many half cell widths wide every pixel is drawn. Used to compare the size of different layouts
+func (l layout) pixelWidth() int {
+	switch l.renderer {
+	case RendererDouble:
+		return 4 * l.scale
+	case RendererBraille:
+		return l.scale
+	}
+	return 2 * l.scale
+}
+
+// fit ... Returns the layout used to draw a width by height frame in a terminal of cols by rows cells.
+// A scale of 0 picks the largest scale that fits, and RendererAuto also picks the renderer. If nothing fits, the smallest layout is used
+func fit(renderer string, scale, width, height, cols, rows int) layout {
+	renderers := []string{renderer}
+	if renderer == RendererAuto {
+		renderers, scale = autoRenderers, 0
+	}
+	if scale > 0 {
+		return layout{renderer, scale}
+	}
+
+	best, bestWidth := layout{renderers[len(renderers)-1], 1}, 0 // the smallest layout, used if nothing fits
+	for _, r := range renderers {
+		for s := 1; ; s++ {
+			l := layout{r, s}
+			if c, rw := l.cells(width, height); c > cols || rw > rows {
+				break
+			}
+			if l.pixelWidth() > bestWidth {
+				best, bestWidth = l, l.pixelWidth()
+			}
+		}
+	}
+	return best
+}
+
+// redraw ... Draws the last frame presented again with a layout fitted to the terminal's current size. Called when the terminal is resized
+func (io TcellIO) redraw() {
+	io.state.mu.Lock()
+	defer io.state.mu.Unlock()
+	if io.state.last != nil {
+		io.draw(io.state.last)
+	}
+}
+
+// draw ... Fits a layout to the terminal and draws fb with it, with the stats line below. state.mu must be held
+func (io TcellIO) draw(fb *framebuffer.Framebuffer) {
+	cols, rows := io.screen.Size()
+	if io.state.stats != "" {
+		rows--
+	}
+	l := fit(io.renderer, io.scale, fb.Width(), fb.Height(), cols, rows)
+	if l != io.state.current {
+		io.screen.Clear()
+		io.state.current = l
+	}
+
+	// pixel returns the palette index of the pixel drawn at a row and column of the scaled frame, and how brightly it glows
+	pixel := func(row, col int) phosphor {
+		if io.state.fade != nil {
+			return io.state.fade.at(row/l.scale, col/l.scale)
+		}
+		return phosphor{fb.Pixel(row/l.scale, col/l.scale), 1}
+	}
+	truecolor := io.screen.Colors() >= 1<<24
+	width, height := l.cells(fb.Width(), fb.Height())
+	w, h := fb.Width()*l.scale, fb.Height()*l.scale
+	for row := 0; row < height; row++ {
+		for col := 0; col < width; col++ {
+			switch l.renderer {
+			case RendererBlock:
+				char, style := io.glyph(pixel(row, col), truecolor)
+				io.screen.SetContent(col, row, char, nil, style)
+			case RendererDouble:
+				char, style := io.glyph(pixel(row, col/2), truecolor)
+				io.screen.SetContent(col, row, char, nil, style)
+			case RendererHalfBlock:
+				top := io.shade(pixel(2*row, col), truecolor)
+				bottom := io.colors[0]
+				if 2*row+1 < h {
+					bottom = io.shade(pixel(2*row+1, col), truecolor)
+				}
+				io.screen.SetContent(col, row, '▀', nil, tcell.StyleDefault.Foreground(top).Background(bottom))
+			case RendererBraille:
+				char, color := io.braille(pixel, 4*row, 2*col, w, h, truecolor)
+				io.screen.SetContent(col, row, char, nil, tcell.StyleDefault.Foreground(color).Background(io.colors[0]))
+			}
+		}
+	}
+	for col, char := range io.state.stats {
+		io.screen.SetContent(col, height, char, nil, io.style)
+	}
+	io.screen.Show()
+}
+
+// braille ... Returns the Braille pattern for the 2x4 block of pixels starting at the given row and column of a w by h scaled frame,
+// and the colour of the palette entry most of its lit pixels have, shaded by the brightest of them.
+// Without truecolor, fading pixels are only raised for the first half of their fade
+func (io TcellIO) braille(pixel func(row, col int) phosphor, row, col, w, h int, truecolor bool) (rune, tcell.Color) {
+	char := brailleBlank
+	var counts [4]int
+	brightest := 0.0
+	for dr := 0; dr < 4 && row+dr < h; dr++ {
+		for dc := 0; dc < 2 && col+dc < w; dc++ {
+			p := pixel(row+dr, col+dc)
+			if p.mask == 0 || (!truecolor && p.glow < 0.5) {
+				continue
+			}
+			char |= brailleDots[dr][dc]
+			counts[p.mask]++
+			brightest = max(brightest, p.glow)
+		}
+	}
+	var mask byte = 1
+	for i := 2; i < len(counts); i++ {
+		if counts[i] > counts[mask] {
+			mask = byte(i)
+		}
+	}
+	return char, io.shade(phosphor{mask, brightest}, truecolor)
+}
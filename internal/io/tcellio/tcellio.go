@@ -2,26 +2,57 @@ package tcellio
 
 import (
 	"fmt"
-	"os"
-	"time"
+	"unicode"
 
+	"github.com/TH3-F001/GoChip-8/chip8/internal/framebuffer"
+	"github.com/TH3-F001/GoChip-8/chip8/internal/io"
+	"github.com/TH3-F001/GoChip-8/chip8/internal/palette"
 	"github.com/gdamore/tcell/v2"
 )
 
 // TcellIO ... Holds state for the active tcellio display. Sh-ould be instantiated using tcellio.New()
-// pixels is a 2-dimensional array of bools representing whether a pixel should be on or off. It's size should be stack once initialized
+// palette holds a style for each combination of lit bitplanes (none, first, second, both)
 // fg and bg are hex values for the display color
 // screen holds the active tcell.Screen instance
 // style holds the tcell.Style instance
-// maxRow and maxCol hold the largest column/row that can be written to. used to limit excessive use of len(pixels) - 1
+// colors holds the colour of each palette entry, for renderers that set the foreground and background colours of a cell separately, and rgb their hex values
+// renderer and scale are how frames are drawn. see render.go
+// hotkeyCh is where ListenForControl sends hotkeys. nil until SetHotkeyChannel is called
+// keys holds the state of the keypad, kept by the goroutine ListenForControl starts
+// state is shared by every copy of the TcellIO
 type TcellIO struct {
-	pixels [][]bool
-	fg     uint32
-	bg     uint32
-	screen tcell.Screen
-	style  tcell.Style
-	maxRow int
-	maxCol int
+	fg       uint32
+	bg       uint32
+	screen   tcell.Screen
+	style    tcell.Style
+	palette  [4]tcell.Style
+	colors   [4]tcell.Color
+	rgb      [4]uint32
+	renderer string
+	scale    int
+	hotkeyCh chan<- io.Hotkey
+	keys     *io.KeyState
+	state    *renderState
+}
+
+// hotkeyMap ... Maps function keys to the hotkeys they trigger
+var hotkeyMap map[tcell.Key]io.Hotkey = map[tcell.Key]io.Hotkey{
+	tcell.KeyF5:         io.HotkeySaveState,
+	tcell.KeyF6:         io.HotkeyPrevSlot,
+	tcell.KeyF7:         io.HotkeyNextSlot,
+	tcell.KeyF8:         io.HotkeyLoadState,
+	tcell.KeyF9:         io.HotkeyRecord,
+	tcell.KeyF12:        io.HotkeyScreenshot,
+	tcell.KeyBackspace:  io.HotkeyRewind,
+	tcell.KeyBackspace2: io.HotkeyRewind,
+}
+
+// keyMap ... Maps the keys of the left of a QWERTY keyboard to the COSMAC VIP's hex keypad
+var keyMap map[rune]byte = map[rune]byte{
+	'1': 0x1, '2': 0x2, '3': 0x3, '4': 0xC,
+	'q': 0x4, 'w': 0x5, 'e': 0x6, 'r': 0xD,
+	'a': 0x7, 's': 0x8, 'd': 0x9, 'f': 0xE,
+	'z': 0xA, 'x': 0x0, 'c': 0xB, 'v': 0xF,
 }
 
 // charMap... A simple booleon map of true/false to on/off pixel runes. Used to prevent excessive if statements
@@ -33,10 +64,19 @@ var charMap map[bool]rune = map[bool]rune{
 
 // New ... Creates a new tcell screen instance, initializes color and screen size, and returns a TCellio instance
 // fg and bg expects colors as hexcodes. red green and blue are split from the hex, and a new tcell color is created
-// returns an error if: rows or cols are less or equal to zero, or if screen creation/initialization fails
-func New(rows, cols int, fgColor, bgColor uint32) (*TcellIO, error) {
-	if rows <= 0 || cols <= 0 {
-		return nil, fmt.Errorf("error in tcellio/New(): Width/Height must be more than zero: rows=%v, cols=%v", rows, cols)
+// custom optionally overrides the colours of each combination of lit XO-CHIP bitplanes. see palette.Resolve
+// renderer is one of the Renderer constants, or "" for RendererAuto. scale is how many times each pixel is repeated across and down, or 0 to fit the terminal
+// persistence is the number of frames pixels take to fade out after turning off. 0 turns them off at once
+// returns an error if the renderer is unknown, persistence is out of range, or if screen creation/initialization fails
+func New(fgColor, bgColor uint32, custom []uint32, renderer string, scale, persistence int) (*TcellIO, error) {
+	if renderer == "" {
+		renderer = RendererAuto
+	}
+	if err := checkRenderer(renderer, scale); err != nil {
+		return nil, fmt.Errorf("error in tcellio/New(): %w", err)
+	}
+	if err := checkPersistence(persistence); err != nil {
+		return nil, fmt.Errorf("error in tcellio/New(): %w", err)
 	}
 
 	screen, err := tcell.NewScreen()
@@ -49,190 +89,118 @@ func New(rows, cols int, fgColor, bgColor uint32) (*TcellIO, error) {
 		return nil, fmt.Errorf("error in tcellio/New(): %w", err)
 	}
 
-	pxs := make([][]bool, rows)
-	for row := range pxs {
-		pxs[row] = make([]bool, cols)
-		for col := range pxs[row] {
-			pxs[row][col] = false
-		}
-	}
-
 	fg := tcell.NewHexColor(int32(fgColor))
 	bg := tcell.NewHexColor(int32(bgColor))
 
 	style := tcell.StyleDefault.Background(bg).Foreground(fg)
 	screen.SetStyle(style)
 
+	colors := palette.Resolve(fgColor, bgColor, custom)
+	var styles [4]tcell.Style
+	var tcellColors [4]tcell.Color
+	for i, color := range colors {
+		tcellColors[i] = tcell.NewHexColor(int32(color))
+		styles[i] = style.Foreground(tcellColors[i])
+	}
+
 	tc := TcellIO{
-		pixels: pxs,
-		fg:     fgColor,
-		bg:     bgColor,
-		screen: screen,
-		style:  style,
-		maxRow: rows - 1,
-		maxCol: cols - 1,
+		fg:       fgColor,
+		bg:       bgColor,
+		screen:   screen,
+		style:    style,
+		palette:  styles,
+		colors:   tcellColors,
+		renderer: renderer,
+		scale:    scale,
+		rgb:      colors,
+		keys:     io.NewKeyState(io.KeyHold),
+		state:    &renderState{},
+	}
+	if persistence > 0 {
+		tc.state.fade = &fade{frames: persistence}
 	}
 
 	return &tc, nil
 }
 
-// GetMaxRow ... Returns the highest pixels row that can be written to (if len(pixels[row] is 32, then maxCol is 31).
-func (io TcellIO) GetMaxRow() int {
-	return io.maxRow
-}
-
-// GetMaxCol ... Returns the highest pixels column that can be written to (if len(pixels[row] is 32, then maxCol is 31).
-func (io TcellIO) GetMaxCol() int {
-	return io.maxCol
-}
-
-// GetPixels ... Returns a copy of the TcellIO.pixels 'array' (slice)
-func (io TcellIO) GetPixels() [][]bool {
-	return io.pixels
-}
-
-// GetPixel ... Returns true if the pixel at the given cell in the pixels array is on, false if it's off, and an error if out of bounds
-func (io TcellIO) GetPixel(row, col int) (bool, error) {
-	if row < 0 || row > io.maxRow || col < 0 || col > io.maxCol {
-		return false, fmt.Errorf("out of bounds request to TcellIO.GetPixel() . request to get pixel at (row:%d, col:%d) is out of bounds. max coordinates: (row:%d, col:%d)", row, col, io.maxRow, io.maxCol)
+// Present ... Draws a completed frame to the screen with the configured renderer, coloured by the bitplanes lit at each pixel.
+// A copy is kept to redraw when the terminal is resized. error is only to conform to the IO interface and isnt used
+func (io *TcellIO) Present(fb *framebuffer.Framebuffer) error {
+	io.state.mu.Lock()
+	defer io.state.mu.Unlock()
+	if last := io.state.last; last != nil && last.Width() == fb.Width() && last.Height() == fb.Height() {
+		last.CopyFrom(fb)
+	} else {
+		io.state.last = fb.Clone()
 	}
-	return io.pixels[row][col], nil
-}
-
-// SetPixel ... Sets the pixel at a given row and column to either on or off. returns an error if out of bounds
-func (io *TcellIO) SetPixel(row, col int, lit bool) error {
-	if row > io.maxRow || row < 0 || col > io.maxCol || col < 0 {
-		return fmt.Errorf("overflow detected in TcellIO.SetPixel(). request to set (row:%d, col:%d) to %v is out of bounds. max coordinates: (row:%d, col:%d)", row, col, lit, io.maxRow, io.maxCol)
+	if io.state.fade != nil {
+		io.state.fade.advance(fb)
 	}
-	io.pixels[row][col] = lit
+	io.draw(io.state.last)
+	return nil // Satisfies the interface
+}
 
-	return nil
+// ShowStats ... Sets the frames per second and instructions per second drawn below the display by the next call to Present
+func (io *TcellIO) ShowStats(fps, ips float64) {
+	io.state.mu.Lock()
+	defer io.state.mu.Unlock()
+	io.state.stats = fmt.Sprintf("FPS %5.1f  IPS %7.0f", fps, ips)
 }
 
-// Refresh ...Iterates over the display's array of pixels, and updates the display to match the array. error is only to conform to the IO interface and isnt used
-func (io *TcellIO) Refresh() error {
-	for row := range io.pixels {
-		for col := range io.pixels[row] {
-			io.screen.SetContent(col, row, charMap[io.pixels[row][col]], nil, io.style)
-		}
-	}
-	io.screen.Show()
-	return nil // Satisfies the interface
+// resize ... Redraws the last frame to fit the terminal's new size
+func (io TcellIO) resize() {
+	io.redraw()
+	io.screen.Sync()
 }
 
-// ListenWait ... waits and listens for the traditonal Chip8 keypresses eturns the corresponding hex code and forwards any errors.
+// ListenWait ... Returns the key pressed since the last call, or io.NoKey if there isnt one. Keys are read by the goroutine ListenForControl starts,
+// and Fx0A repeats until it gets a key, so never blocks. Keys pressed before the first call of a wait dont count, so a wait is only ended by a fresh press
 func (io TcellIO) ListenWait() (byte, error) {
-	event := io.screen.PollEvent()
-	switch event := event.(type) {
-	case *tcell.EventResize:
-		io.screen.Sync()
-	case *tcell.EventKey:
-		switch event.Rune() {
-		case '1':
-			return 0x1, nil
-		case '2':
-			return 0x2, nil
-		case '3':
-			return 0x3, nil
-		case '4':
-			return 0xC, nil
-		case 'q':
-			return 0x4, nil
-		case 'w':
-			return 0x5, nil
-		case 'e':
-			return 0x6, nil
-		case 'r':
-			return 0xD, nil
-		case 'a':
-			return 0x7, nil
-		case 's':
-			return 0x8, nil
-		case 'd':
-			return 0x9, nil
-		case 'f':
-			return 0xE, nil
-		case 'z':
-			return 0xA, nil
-		case 'x':
-			return 0x0, nil
-		case 'c':
-			return 0xB, nil
-		case 'v':
-			return 0xF, nil
-
-		}
-	}
-	return 255, nil
+	return io.keys.ListenWait()
 }
 
-// ListenNow ... listens for currently pressed Chip8 keypresses, returns the corresponding hex code and forwards any errors.
+// ListenNow ... Returns the last key pressed if it is still held down, or any other key that is. io.NoKey if none are
 func (io TcellIO) ListenNow() (byte, error) {
-	events := make(chan tcell.Event)
-	defer close(events)
-
-	go func() {
-		for {
-			events <- io.screen.PollEvent()
-		}
-	}()
+	return io.keys.ListenNow()
+}
 
-	select {
-	case event := <-events:
-		if key, ok := event.(*tcell.EventKey); ok {
-			switch key.Rune() {
-			case '1':
-				return 0x1, nil
-			case '2':
-				return 0x2, nil
-			case '3':
-				return 0x3, nil
-			case '4':
-				return 0xC, nil
-			case 'q':
-				return 0x4, nil
-			case 'w':
-				return 0x5, nil
-			case 'e':
-				return 0x6, nil
-			case 'r':
-				return 0xD, nil
-			case 'a':
-				return 0x7, nil
-			case 's':
-				return 0x8, nil
-			case 'd':
-				return 0x9, nil
-			case 'f':
-				return 0xE, nil
-			case 'z':
-				return 0xA, nil
-			case 'x':
-				return 0x0, nil
-			case 'c':
-				return 0xB, nil
-			case 'v':
-				return 0xF, nil
-			}
-		}
-	case <-time.After(10 * time.Millisecond):
-		return 255, nil
-	}
+// KeyDown ... Returns true if the given hex key is held down, which is for as long as the terminal keeps repeating it
+func (io TcellIO) KeyDown(key byte) bool {
+	return io.keys.KeyDown(key)
+}
 
-	return 255, nil
+// SetHotkeyChannel ... Sets the channel that ListenForControl sends hotkeys (F5: save state, F6/F7: previous/next slot, F8: load state, F9: start/stop recording, F12: screenshot, Backspace: rewind) to
+func (io *TcellIO) SetHotkeyChannel(hotkeyCh chan<- io.Hotkey) {
+	io.hotkeyCh = hotkeyCh
 }
 
-// ListenForControl ... Specifically listens for user interupts to terminate the program. (meant to be run concurrently)
+// ListenForControl ... Starts the goroutine that reads every terminal event: user interupts to terminate the program, hotkeys, and the hex keys
+// ListenWait, ListenNow and KeyDown report. There is only ever one reader, so no event is taken by anything else
 func (io TcellIO) ListenForControl(termCh chan<- bool) {
 	go func() {
 		for {
 			event := io.screen.PollEvent()
+			if event == nil {
+				return // The screen has been finalized
+			}
 			switch event := event.(type) {
+			case *tcell.EventResize:
+				io.resize()
 			case *tcell.EventKey:
 				if event.Key() == tcell.KeyEscape || event.Key() == tcell.KeyCtrlC {
 					termCh <- true
 					return // Exit the goroutine when termination key is pressed
 				}
+				if hotkey, ok := hotkeyMap[event.Key()]; ok && io.hotkeyCh != nil {
+					select {
+					case io.hotkeyCh <- hotkey:
+					default:
+					}
+				}
+				// caps lock or shift shouldnt change the key
+				if key, ok := keyMap[unicode.ToLower(event.Rune())]; ok && event.Key() == tcell.KeyRune {
+					io.keys.Press(key)
+				}
 			}
 		}
 	}()
@@ -242,9 +210,8 @@ func Beep() {
 	print("Making my own library")
 }
 
-// Terminate ... Clears the screen, destroys it, and exits the program
+// Terminate ... Clears the screen and destroys it
 func (io *TcellIO) Terminate() {
 	io.screen.Clear()
 	io.screen.Fini()
-	os.Exit(1)
 }
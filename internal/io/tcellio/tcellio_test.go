@@ -0,0 +1,77 @@
+package tcellio
+
+import (
+	"testing"
+	"time"
+
+	"github.com/TH3-F001/GoChip-8/chip8/internal/io"
+	"github.com/gdamore/tcell/v2"
+)
+
+// newSimulated ... Creates a TcellIO drawing to a simulated screen, and starts its event loop
+func newSimulated(t *testing.T) (*TcellIO, tcell.SimulationScreen, chan bool) {
+	t.Helper()
+	screen := tcell.NewSimulationScreen("")
+	if err := screen.Init(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(screen.Fini)
+	tc := &TcellIO{screen: screen, keys: io.NewKeyState(io.KeyHold), state: &renderState{}}
+	termCh := make(chan bool, 1)
+	tc.ListenForControl(termCh)
+	return tc, screen, termCh
+}
+
+// waitFor ... Fails the test if cond doesnt become true within a second
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); !cond(); time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+	}
+}
+
+func TestKeysAreReadByTheEventLoop(t *testing.T) {
+	tc, screen, _ := newSimulated(t)
+
+	// ListenNow used to start a goroutine per call and close its channel on return, so polling it and then pressing a key panicked
+	for i := 0; i < 100; i++ {
+		if key, err := tc.ListenNow(); err != nil || key != io.NoKey {
+			t.Fatalf("ListenNow() = %#x, %v with no key pressed", key, err)
+		}
+	}
+	if key, _ := tc.ListenWait(); key != io.NoKey {
+		t.Fatalf("ListenWait() = %#x with no key pressed, want it not to block", key)
+	}
+
+	screen.InjectKey(tcell.KeyRune, 'W', tcell.ModShift)
+	waitFor(t, "key 5 to be held", func() bool { return tc.KeyDown(0x5) })
+	if key, _ := tc.ListenNow(); key != 0x5 {
+		t.Errorf("ListenNow() = %#x, want 0x5", key)
+	}
+	if tc.KeyDown(0x4) {
+		t.Error("KeyDown(0x4) = true, want only 0x5 held")
+	}
+
+	tc.ListenWait()
+	screen.InjectKey(tcell.KeyRune, 'v', 0)
+	var key byte = io.NoKey
+	waitFor(t, "ListenWait to take key F", func() bool {
+		key, _ = tc.ListenWait()
+		return key != io.NoKey
+	})
+	if key != 0xF {
+		t.Errorf("ListenWait() = %#x, want 0xF", key)
+	}
+}
+
+func TestEscapeRequestsTermination(t *testing.T) {
+	_, screen, termCh := newSimulated(t)
+	screen.InjectKey(tcell.KeyEscape, 0, 0)
+	select {
+	case <-termCh:
+	case <-time.After(time.Second):
+		t.Fatal("Escape didnt request termination")
+	}
+}
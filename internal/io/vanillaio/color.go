@@ -0,0 +1,83 @@
+package vanillaio
+
+import "fmt"
+
+// Colour modes ... The number of colours VanillaIO can use. Colours are given as hex RGB and drawn with the closest colour the mode has
+const (
+	// Colors256 ... The xterm 256 colour palette. Only its 6x6x6 colour cube and grey ramp are used, since the first 16 colours vary with the terminal's theme
+	Colors256 = 256
+	// Colors16 ... The 16 standard ANSI colours, for terminals that dont support any more
+	Colors16 = 16
+)
+
+// ansi16 ... The usual RGB values of the 16 standard ANSI colours, in order
+var ansi16 = [16]uint32{
+	0x000000, 0x800000, 0x008000, 0x808000, 0x000080, 0x800080, 0x008080, 0xC0C0C0,
+	0x808080, 0xFF0000, 0x00FF00, 0xFFFF00, 0x0000FF, 0xFF00FF, 0x00FFFF, 0xFFFFFF,
+}
+
+// cubeLevels ... The intensity of each of the 6 steps of every channel of the xterm 256 colour cube
+var cubeLevels = [6]uint32{0x00, 0x5F, 0x87, 0xAF, 0xD7, 0xFF}
+
+// checkColors ... Returns an error if colors isnt one of the colour modes above
+func checkColors(colors int) error {
+	if colors != Colors256 && colors != Colors16 {
+		return fmt.Errorf("invalid colour mode %d. must be %d or %d", colors, Colors256, Colors16)
+	}
+	return nil
+}
+
+// distance ... Returns the squared distance between two hex RGB colours
+func distance(a, b uint32) int {
+	sum := 0
+	for shift := 0; shift <= 16; shift += 8 {
+		d := int(a>>shift&0xFF) - int(b>>shift&0xFF)
+		sum += d * d
+	}
+	return sum
+}
+
+// nearest ... Returns the index of the colour closest to rgb in the given colour mode
+func nearest(rgb uint32, colors int) int {
+	best, bestDistance := 0, -1
+	consider := func(index int, candidate uint32) {
+		if d := distance(rgb, candidate); bestDistance < 0 || d < bestDistance {
+			best, bestDistance = index, d
+		}
+	}
+	if colors == Colors16 {
+		for i, candidate := range ansi16 {
+			consider(i, candidate)
+		}
+		return best
+	}
+	for r := range cubeLevels {
+		for g := range cubeLevels {
+			for b := range cubeLevels {
+				consider(16+36*r+6*g+b, cubeLevels[r]<<16|cubeLevels[g]<<8|cubeLevels[b])
+			}
+		}
+	}
+	for i := 0; i < 24; i++ {
+		level := uint32(8 + 10*i)
+		consider(232+i, level<<16|level<<8|level)
+	}
+	return best
+}
+
+// sgr ... Returns the escape sequence that sets the foreground and background to the given colour indexes of the colour mode
+func sgr(fg, bg, colors int) string {
+	if colors == Colors16 {
+		return fmt.Sprintf("\033[%d;%dm", ansi16Code(fg, 30), ansi16Code(bg, 40))
+	}
+	return fmt.Sprintf("\033[38;5;%d;48;5;%dm", fg, bg)
+}
+
+// ansi16Code ... Returns the SGR parameter for one of the 16 standard colours. base is 30 for the foreground and 40 for the background.
+// The bright colours are 60 past their dark counterparts
+func ansi16Code(index, base int) int {
+	if index >= 8 {
+		return base + 60 + index - 8
+	}
+	return base + index
+}
@@ -0,0 +1,61 @@
+package vanillaio
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/TH3-F001/GoChip-8/chip8/internal/capture"
+	"github.com/TH3-F001/GoChip-8/chip8/internal/framebuffer"
+	"github.com/TH3-F001/GoChip-8/chip8/internal/termgfx"
+)
+
+// detectTimeout ... How long to wait for the terminal to reply to termgfx.Query before drawing characters instead
+const detectTimeout = 500 * time.Millisecond
+
+// kittyImageID ... The id of the image every frame is drawn as with the kitty graphics protocol, so each frame replaces the last
+const kittyImageID = 1
+
+// detect ... Sends termgfx.Query and waits up to detectTimeout for the terminal to reply. The keyboard is already being read, so the replies
+// arrive through parse. A terminal that doesnt reply at all gets a Detection that supports nothing
+func (v *VanillaIO) detect() *termgfx.Detection {
+	detection := termgfx.NewDetection()
+	os.Stdout.WriteString(termgfx.Query)
+	timeout := time.After(detectTimeout)
+	for !detection.Done {
+		select {
+		case seq := <-v.replies:
+			detection.Reply(seq)
+		case <-timeout:
+			return detection
+		}
+	}
+	return detection
+}
+
+// drawBitmap ... Writes fb to buf as a bitmap, scaled up by the largest whole number that fits in the terminal and centered in it.
+// The screen is cleared when the terminal is resized or the resolution changes, so that no part of a bigger frame is left behind. mu must be held
+func (v *VanillaIO) drawBitmap(buf *bytes.Buffer, fb *framebuffer.Framebuffer, cols, rows int) error {
+	if cols != v.cols || rows != v.rows || fb.Width() != v.width {
+		v.cols, v.rows, v.width = cols, rows, fb.Width()
+		buf.WriteString("\033[0m\033[2J")
+	}
+	// a sixel image reaching the last row scrolls the screen, so sixels are kept above it
+	if v.protocol == termgfx.Sixel {
+		rows = max(rows-1, 1)
+	}
+	cellWidth, cellHeight := v.cell[0], v.cell[1]
+	scale := max(min(cols*cellWidth/fb.Width(), rows*cellHeight/fb.Height()), 1)
+	width := (fb.Width()*scale + cellWidth - 1) / cellWidth
+	height := (fb.Height()*scale + cellHeight - 1) / cellHeight
+	left, top := max((cols-width)/2, 0), max((rows-height)/2, 0)
+
+	fmt.Fprintf(buf, "\033[%d;%dH", top+1, left+1)
+	v.cursor = [2]int{-1, -1}
+	img := capture.Image(fb, v.rgb, scale)
+	if v.protocol == termgfx.Kitty {
+		return termgfx.EncodeKitty(buf, img, kittyImageID)
+	}
+	return termgfx.EncodeSixel(buf, img)
+}
@@ -0,0 +1,190 @@
+package vanillaio
+
+import (
+	"bytes"
+	"os"
+	"time"
+
+	"github.com/TH3-F001/GoChip-8/chip8/internal/io"
+)
+
+// keyMap ... Maps the keys of the left of a QWERTY keyboard to the COSMAC VIP's hex keypad
+var keyMap map[byte]byte = map[byte]byte{
+	'1': 0x1, '2': 0x2, '3': 0x3, '4': 0xC,
+	'q': 0x4, 'w': 0x5, 'e': 0x6, 'r': 0xD,
+	'a': 0x7, 's': 0x8, 'd': 0x9, 'f': 0xE,
+	'z': 0xA, 'x': 0x0, 'c': 0xB, 'v': 0xF,
+}
+
+// hotkeyMap ... Maps the escape sequences sent by function keys to the hotkeys they trigger. The same keys as tcellio
+var hotkeyMap map[string]io.Hotkey = map[string]io.Hotkey{
+	"\033[15~": io.HotkeySaveState,
+	"\033[17~": io.HotkeyPrevSlot,
+	"\033[18~": io.HotkeyNextSlot,
+	"\033[19~": io.HotkeyLoadState,
+	"\033[20~": io.HotkeyRecord,
+	"\033[24~": io.HotkeyScreenshot,
+}
+
+// Control characters ... The bytes sent by the keys that terminate the program or rewind. Raw mode turns off the terminal's own handling of Ctrl+C
+const (
+	keyEscape    = 0x1B
+	keyCtrlC     = 0x03
+	keyBackspace = 0x7F
+	keyCtrlH     = 0x08
+)
+
+// maxPending ... The longest an unfinished escape sequence can get before it is given up on and dropped
+const maxPending = 4096
+
+// escapeTimeout ... How long an escape at the end of the input waits for the rest of an escape sequence before it is taken as the Escape key.
+// Terminals send a sequence all at once, but it can still be split across reads
+const escapeTimeout = 50 * time.Millisecond
+
+// read ... Reads the keyboard until standard input is closed. Runs on a goroutine of its own, started by New
+// An escape sequence cut off at the end of a read is kept in pending until the rest of it arrives. A lone escape is only kept for escapeTimeout
+func (v *VanillaIO) read() {
+	chunks := make(chan []byte)
+	go func() {
+		defer close(chunks)
+		for {
+			buf := make([]byte, 256)
+			n, err := os.Stdin.Read(buf)
+			if err != nil {
+				return
+			}
+			chunks <- buf[:n]
+		}
+	}()
+
+	var pending []byte
+	var timeout <-chan time.Time
+	for {
+		idle := false
+		select {
+		case chunk, ok := <-chunks:
+			if !ok {
+				return
+			}
+			pending = append(pending, chunk...)
+		case <-timeout:
+			idle = true
+		}
+		v.mu.Lock()
+		used := v.parse(pending, idle)
+		v.mu.Unlock()
+		pending = append(pending[:0], pending[used:]...)
+		if len(pending) > maxPending {
+			pending = pending[:0]
+		}
+		timeout = nil
+		if len(pending) == 1 && pending[0] == keyEscape {
+			timeout = time.After(escapeTimeout)
+		}
+	}
+}
+
+// parse ... Acts on every key in a chunk of input, and returns the number of bytes used. A lone escape terminates the program, while an escape followed by [ or O
+// starts an escape sequence that runs until its final byte, and one followed by _ a command string that runs until ESC \. Sequences that arent hotkeys are
+// passed on to reply. A sequence cut off at the end of the chunk isnt used, so that it can be parsed whole once the rest of it arrives.
+// Neither is an escape at the very end, since it may be the start of one, unless idle is true because nothing has followed it for escapeTimeout. mu must be held
+func (v *VanillaIO) parse(input []byte, idle bool) int {
+	for i := 0; i < len(input); i++ {
+		switch b := input[i]; {
+		case b == keyEscape && i+1 < len(input) && input[i+1] == '_':
+			end := bytes.Index(input[i+2:], []byte("\033\\"))
+			if end < 0 {
+				return i
+			}
+			end += i + 3
+			v.reply(string(input[i : end+1]))
+			i = end
+		case b == keyEscape && i+1 < len(input) && (input[i+1] == '[' || input[i+1] == 'O'):
+			end := i + 2
+			for end < len(input) && (input[end] < 0x40 || input[end] > 0x7E) {
+				end++
+			}
+			if end == len(input) {
+				return i
+			}
+			seq := string(input[i : end+1])
+			if hotkey, ok := hotkeyMap[seq]; ok {
+				v.sendHotkey(hotkey)
+			} else {
+				v.reply(seq)
+			}
+			i = end
+		case b == keyEscape && i+1 == len(input) && !idle:
+			return i
+		case b == keyEscape || b == keyCtrlC:
+			v.requestTermination()
+		case b == keyBackspace || b == keyCtrlH:
+			v.sendHotkey(io.HotkeyRewind)
+		default:
+			if b >= 'A' && b <= 'Z' {
+				b += 'a' - 'A' // caps lock or shift shouldnt change the key
+			}
+			if key, ok := keyMap[b]; ok {
+				v.keys.Press(key)
+			}
+		}
+	}
+	return len(input)
+}
+
+// reply ... Sends an escape sequence that isnt a hotkey to replies, where detect waits for the terminal's replies to its query. Dropped if replies is full. mu must be held
+func (v *VanillaIO) reply(seq string) {
+	select {
+	case v.replies <- seq:
+	default:
+	}
+}
+
+// sendHotkey ... Sends a hotkey if SetHotkeyChannel has been called. Hotkeys are dropped if the channel is full. mu must be held
+func (v *VanillaIO) sendHotkey(hotkey io.Hotkey) {
+	if v.hotkeyCh == nil {
+		return
+	}
+	select {
+	case v.hotkeyCh <- hotkey:
+	default:
+	}
+}
+
+// requestTermination ... Asks the program to terminate, if ListenForControl has been called. Sent on a goroutine of its own so that input
+// keeps being read even if no one is listening anymore. mu must be held
+func (v *VanillaIO) requestTermination() {
+	if termCh := v.termCh; termCh != nil {
+		go func() { termCh <- true }()
+	}
+}
+
+// ListenWait ... Returns the key pressed since the last call, or io.NoKey if there isnt one. Fx0A repeats until it gets a key, so never blocks.
+// Keys pressed before the first call of a wait dont count, so a wait is only ended by a fresh press
+func (v *VanillaIO) ListenWait() (byte, error) {
+	return v.keys.ListenWait()
+}
+
+// ListenNow ... Returns the last key pressed if it is still held down, or any other key that is. io.NoKey if none are
+func (v *VanillaIO) ListenNow() (byte, error) {
+	return v.keys.ListenNow()
+}
+
+// KeyDown ... Returns true if the given hex key is held down, which is for as long as the terminal keeps repeating it
+func (v *VanillaIO) KeyDown(key byte) bool {
+	return v.keys.KeyDown(key)
+}
+
+// SetHotkeyChannel ... Sets the channel that hotkeys (F5: save state, F6/F7: previous/next slot, F8: load state, F9: start/stop recording, F12: screenshot, Backspace: rewind) are sent to
+func (v *VanillaIO) SetHotkeyChannel(hotkeyCh chan<- io.Hotkey) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.hotkeyCh = hotkeyCh
+}
+
+// ListenForControl ... Sends to termCh when Escape or Ctrl+C is pressed. The keyboard is already read by the goroutine New started, so doesnt start one of its own
+func (v *VanillaIO) ListenForControl(termCh chan<- bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.termCh = termCh
+}
@@ -0,0 +1,57 @@
+package vanillaio
+
+import (
+	"testing"
+	"time"
+
+	"github.com/TH3-F001/GoChip-8/chip8/internal/io"
+)
+
+func TestParseEscape(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		idle      bool
+		wantUsed  int
+		wantTerm  bool
+		wantReply string
+		wantKey   byte
+	}{
+		{name: "escape at the end waits for more input", input: "w\033", wantUsed: 1, wantKey: 0x5},
+		{name: "escape at the end is the Escape key once idle", input: "\033", idle: true, wantUsed: 1, wantTerm: true, wantKey: io.NoKey},
+		{name: "escape followed by a key is the Escape key", input: "\033w", wantUsed: 2, wantTerm: true, wantKey: 0x5},
+		{name: "escape completed by the next read is a sequence", input: "\033[A", wantUsed: 3, wantReply: "\033[A", wantKey: io.NoKey},
+		{name: "unfinished sequence waits for more input", input: "\033[1", idle: true, wantUsed: 0, wantKey: io.NoKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			termCh := make(chan bool, 1)
+			v := &VanillaIO{keys: io.NewKeyState(io.KeyHold), replies: make(chan string, 1), termCh: termCh}
+			if used := v.parse([]byte(tt.input), tt.idle); used != tt.wantUsed {
+				t.Errorf("parse(%q) = %d, want %d", tt.input, used, tt.wantUsed)
+			}
+			// termination is requested on a goroutine of its own
+			select {
+			case <-termCh:
+				if !tt.wantTerm {
+					t.Error("Escape requested termination")
+				}
+			case <-time.After(50 * time.Millisecond):
+				if tt.wantTerm {
+					t.Error("Escape didnt request termination")
+				}
+			}
+			reply := ""
+			select {
+			case reply = <-v.replies:
+			default:
+			}
+			if reply != tt.wantReply {
+				t.Errorf("reply = %q, want %q", reply, tt.wantReply)
+			}
+			if key, _ := v.keys.ListenNow(); key != tt.wantKey {
+				t.Errorf("ListenNow() = %#x, want %#x", key, tt.wantKey)
+			}
+		})
+	}
+}
@@ -1,87 +1,185 @@
 package vanillaio
 
 import (
-	"errors"
+	"bytes"
 	"fmt"
+	"os"
+	"sync"
+
+	"github.com/TH3-F001/GoChip-8/chip8/internal/capture"
+	"github.com/TH3-F001/GoChip-8/chip8/internal/framebuffer"
+	"github.com/TH3-F001/GoChip-8/chip8/internal/io"
+	"github.com/TH3-F001/GoChip-8/chip8/internal/palette"
+	"github.com/TH3-F001/GoChip-8/chip8/internal/termgfx"
+	"golang.org/x/term"
 )
 
+// cell ... The palette entries of the two pixels drawn in a single terminal cell, one above the other
+type cell struct {
+	top    byte
+	bottom byte
+}
+
+// VanillaIO ... Draws to the terminal with plain ANSI escape sequences, and reads the keyboard from it in raw mode. Should be instantiated using vanillaio.New()
+// Two pixels are drawn in every cell using the upper half block character, so pixels are square. Only the cells that changed since the last frame are redrawn.
+// Terminals that support a graphics protocol get the frame as a scaled bitmap instead. see graphics.go
+// colors is the colour mode, and palette holds the index of each combination of lit bitplanes (none, first, second, both) in it
+// protocol is the graphics protocol frames are drawn with, or termgfx.None for characters. rgb holds the exact colours bitmaps are drawn in,
+// and cell the width and height of a cell in pixels
+// in and out are the terminal's file descriptors, and state the terminal's state before raw mode, restored by Terminate
+// keys holds the state of the keypad, and replies is where escape sequences that arent hotkeys, such as the terminal's replies to queries, are sent. see input.go
+// mu guards everything below it. cells holds what was last drawn in each cell of a frame width cells wide, and cols and rows the terminal's size when it was drawn.
+// cursor is the zero based row and column the cursor was left at, or -1 when unknown. style is the cell whose colours were last set, if styled
+// hotkeyCh and termCh are where hotkeys and termination requests are sent. nil until SetHotkeyChannel and ListenForControl are called
 type VanillaIO struct {
-	Pixels [][]byte
-	fg     uint32
-	bg     uint32
+	colors   int
+	palette  [4]int
+	protocol termgfx.Protocol
+	rgb      capture.Palette
+	cell     [2]int
+	in       int
+	out      int
+	state    *term.State
+	keys     *io.KeyState
+	replies  chan string
+
+	mu       sync.Mutex
+	cells    []cell
+	width    int
+	cols     int
+	rows     int
+	cursor   [2]int
+	style    cell
+	styled   bool
+	hotkeyCh chan<- io.Hotkey
+	termCh   chan<- bool
 }
 
-func New(width, height int, fgColor, bgColor uint32) (*VanillaIO, error) {
-	if width <= 0 || height <= 0 {
-		return &VanillaIO{}, fmt.Errorf("error in ansi/VanillaIO.NewDisplay(): display must be at least 1px wide and 1px tall. supplied size: %dx%d", width, height)
+// New ... Puts the terminal in raw mode, switches to its alternate screen, and starts reading the keyboard
+// fg and bg expect colors as hexcodes, and are drawn with the closest colour of the colour mode. custom optionally overrides the colours of each combination
+// of lit XO-CHIP bitplanes. see palette.Resolve
+// colors is one of the colour modes in color.go, or 0 for Colors256. It is only used for characters, bitmaps are drawn in the exact colours
+// graphics is the protocol to draw bitmaps with. termgfx.Detect asks the terminal which it supports, and draws characters if it supports neither
+// returns an error if the colour mode is unknown, or standard input and output arent a terminal
+func New(fgColor, bgColor uint32, custom []uint32, colors int, graphics termgfx.Protocol) (*VanillaIO, error) {
+	if colors == 0 {
+		colors = Colors256
 	}
-
-	if fgColor >= 255 || bgColor >= 255 {
-		return &VanillaIO{}, fmt.Errorf("error in ansi/VanillaIO.NewDisplay(): VanillaIO only supports colors between 0 and 255. supplied colors: fg=%d, bg=%d", fgColor, bgColor)
+	if err := checkColors(colors); err != nil {
+		return nil, fmt.Errorf("error in vanillaio/New(): %w", err)
 	}
-
-	pxs := make([][]byte, height)
-	for i := range pxs {
-		for j := 0; j <= width; j++ {
-			pxs[i][j] = 0
-		}
+	in, out := int(os.Stdin.Fd()), int(os.Stdout.Fd())
+	if !term.IsTerminal(in) || !term.IsTerminal(out) {
+		return nil, fmt.Errorf("error in vanillaio/New(): standard input and output must be a terminal")
 	}
 
-	// hide cursor while the display is active, make it visible on termination
-	fmt.Print("\033[?25l")
+	v := VanillaIO{colors: colors, in: in, out: out, cursor: [2]int{-1, -1}, keys: io.NewKeyState(io.KeyHold), replies: make(chan string, 16)}
+	v.rgb = capture.NewPalette(fgColor, bgColor, custom)
+	for i, color := range palette.Resolve(fgColor, bgColor, custom) {
+		v.palette[i] = nearest(color, colors)
+	}
 
-	return &VanillaIO{pxs, fgColor, bgColor}, nil
-}
+	state, err := term.MakeRaw(in)
+	if err != nil {
+		return nil, fmt.Errorf("error in vanillaio/New(): %w", err)
+	}
+	v.state = state
+	// switch to the alternate screen and hide the cursor while the display is active. Terminate switches back
+	os.Stdout.WriteString("\033[?1049h\033[?25l\033[2J")
 
-func (io VanillaIO) GetPixels() *[][]byte {
-	return &io.Pixels
+	go v.read()
+	if graphics != termgfx.None {
+		detection := v.detect()
+		v.cell = detection.Cell
+		v.protocol = graphics
+		if graphics == termgfx.Detect {
+			v.protocol = detection.Best()
+		}
+	}
+	return &v, nil
 }
 
-func (io VanillaIO) GetPixel(col, row byte) byte {
-	return io.Pixels[row][col]
-}
+// Present ... Draws a completed frame centered in the terminal, as a bitmap if there is a graphics protocol and as characters otherwise
+func (v *VanillaIO) Present(fb *framebuffer.Framebuffer) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
 
-func (io *VanillaIO) SetPixel(row, col byte, lit byte) error {
-	irow := int(row)
-	icol := int(col)
-	if irow < 0 || irow >= len(io.Pixels) || icol < 0 || icol >= len(io.Pixels[row]) {
-		return errors.New("error in ansi/VanillaIO.setpixel(): display coordinates out of bounds")
+	var buf bytes.Buffer
+	cols, rows, err := term.GetSize(v.out)
+	if err != nil {
+		return fmt.Errorf("error in vanillaio/VanillaIO.Present(): %w", err)
 	}
-
-	px := &io.Pixels[row][col]
-	if lit != 0 {
-		*px = 1
+	if v.protocol != termgfx.None {
+		err = v.drawBitmap(&buf, fb, cols, rows)
 	} else {
-		*px = 0
+		v.drawCells(&buf, fb, cols, rows)
+	}
+	if err != nil {
+		return fmt.Errorf("error in vanillaio/VanillaIO.Present(): %w", err)
+	}
+	if _, err := os.Stdout.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("error in vanillaio/VanillaIO.Present(): %w", err)
 	}
 	return nil
 }
 
-func (io VanillaIO) Listen() (byte, error) {
-	return 0x0a, nil
-}
+// drawCells ... Writes the cells of fb that changed since the last frame to buf. The whole frame is drawn again when the terminal is resized.
+// Frames bigger than the terminal are cropped. mu must be held
+func (v *VanillaIO) drawCells(buf *bytes.Buffer, fb *framebuffer.Framebuffer, cols, rows int) {
+	width, height := fb.Width(), (fb.Height()+1)/2
+	if cols != v.cols || rows != v.rows || width != v.width || len(v.cells) != width*height {
+		// everything has moved, so start over from a blank screen
+		v.cols, v.rows, v.width = cols, rows, width
+		v.cells = nil
+		v.styled = false
+		buf.WriteString("\033[0m\033[2J")
+	}
+	full := v.cells == nil
+	if full {
+		v.cells = make([]cell, width*height)
+	}
 
-func (io VanillaIO) Terminate() error {
-	// make the cursor visible
-	fmt.Print("\033[?25h")
-	return nil
+	left, top := max((cols-width)/2, 0), max((rows-height)/2, 0)
+	for row := 0; row < min(height, rows); row++ {
+		for col := 0; col < min(width, cols); col++ {
+			c := cell{top: fb.Pixel(2*row, col)}
+			if 2*row+1 < fb.Height() {
+				c.bottom = fb.Pixel(2*row+1, col)
+			}
+			i := row*width + col
+			if !full && v.cells[i] == c {
+				continue
+			}
+			v.cells[i] = c
+			v.draw(buf, top+row, left+col, c)
+		}
+	}
 }
 
-// Refreshes the display to reflect the contents of display.Pixels (does notpackage ansi
-func (d VanillaIO) Refresh() error {
-	if len(d.Pixels) <= 0 || len(d.Pixels[0]) <= 0 {
-		return fmt.Errorf("error in ansi/VanillaIO.Refresh(): display size is not in displayable bounds. display size: %dx%d, expected values over zero.", len(d.Pixels[0]), len(d.Pixels))
-	}
-	for row := 0; row < len(d.Pixels); row++ {
-		for col := 0; col < len(d.Pixels[row]); col++ {
-			moveCursor(row, col)
-			fmt.Print(d.Pixels[row][col])
-		}
-		fmt.Print("\n")
+// draw ... Writes the escape sequences and character that draw c at the given zero based row and column of the terminal to buf.
+// The cursor is only moved, and the colours only set, when they arent already where and what they need to be. mu must be held
+func (v *VanillaIO) draw(buf *bytes.Buffer, row, col int, c cell) {
+	if v.cursor != [2]int{row, col} {
+		fmt.Fprintf(buf, "\033[%d;%dH", row+1, col+1)
+	}
+	if !v.styled || v.style != c {
+		buf.WriteString(sgr(v.palette[c.top], v.palette[c.bottom], v.colors))
+		v.style, v.styled = c, true
+	}
+	buf.WriteString("▀")
+	v.cursor = [2]int{row, col + 1}
+	if col+1 >= v.cols {
+		// the cursor doesnt move past the last column, and some terminals wrap it to the next line instead
+		v.cursor = [2]int{-1, -1}
 	}
-	return nil
 }
 
-func moveCursor(row, col int) {
-	fmt.Printf("\033[%d;%dH", row, col)
+// Terminate ... Restores the terminal's colours, cursor, screen and mode. mu is never unlocked, so frames presented afterwards cant draw over the restored screen
+func (v *VanillaIO) Terminate() {
+	v.mu.Lock()
+	if v.protocol == termgfx.Kitty {
+		os.Stdout.WriteString(termgfx.KittyClear)
+	}
+	os.Stdout.WriteString("\033[0m\033[2J\033[?25h\033[?1049l")
+	term.Restore(v.in, v.state)
 }
@@ -0,0 +1,146 @@
+package webio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/TH3-F001/GoChip-8/chip8/internal/framebuffer"
+	"github.com/TH3-F001/GoChip-8/chip8/internal/io"
+)
+
+// The messages sent over the WebSocket are binary, and start with a byte saying what they are.
+// The page is sent:
+//
+//	P r g b r g b r g b r g b              the colour of each combination of lit bitplanes (none, first, second, both)
+//	F w w h h pixels...                    a whole frame, w pixels wide and h high (big endian), with a byte per pixel holding its lit bitplanes
+//	D w w h h (row row pixels...)...       the rows of the frame that changed since the last one, each a big endian row number and its pixels
+//	S on                                   the beep starting (1) or stopping (0)
+//	A pattern... rate                      the 16 bytes of the audio pattern the beep loops, and the rate its bits are played at in Hz (big endian float32)
+//	X                                      the emulator exiting
+//
+// and sends back:
+//
+//	K key down                             a hex key pressed (1) or released (0)
+//	H hotkey                               a hotkey, one of the hotkeyCodes
+//	Q                                      a request to terminate the emulator
+
+// Message types ... The first byte of every message
+const (
+	msgPalette byte = 'P'
+	msgFrame   byte = 'F'
+	msgDiff    byte = 'D'
+	msgSound   byte = 'S'
+	msgPattern byte = 'A'
+	msgExit    byte = 'X'
+	msgKey     byte = 'K'
+	msgHotkey  byte = 'H'
+	msgQuit    byte = 'Q'
+)
+
+// hotkeyCodes ... The hotkey each code of an H message stands for. Named by letter rather than io.Hotkey's value, so the page doesnt depend on its order
+var hotkeyCodes = map[byte]io.Hotkey{
+	's': io.HotkeySaveState,
+	'l': io.HotkeyLoadState,
+	'n': io.HotkeyNextSlot,
+	'p': io.HotkeyPrevSlot,
+	'r': io.HotkeyRewind,
+	'c': io.HotkeyScreenshot,
+	'v': io.HotkeyRecord,
+}
+
+// input ... A message from the page, decoded by decodeInput. kind is msgKey, msgHotkey or msgQuit
+type input struct {
+	kind   byte
+	key    byte
+	down   bool
+	hotkey io.Hotkey
+}
+
+// encodePalette ... Returns the P message for a palette of hex RGB colours
+func encodePalette(palette [4]uint32) []byte {
+	msg := []byte{msgPalette}
+	for _, color := range palette {
+		msg = append(msg, byte(color>>16), byte(color>>8), byte(color))
+	}
+	return msg
+}
+
+// encodeFrame ... Returns the message that brings a page showing prev up to fb: an F message if prev is nil or a different size,
+// otherwise a D message with the rows that changed, or nil if none did
+func encodeFrame(prev, fb *framebuffer.Framebuffer) []byte {
+	width, height := fb.Width(), fb.Height()
+	full := prev == nil || prev.Width() != width || prev.Height() != height
+	kind := msgDiff
+	if full {
+		kind = msgFrame
+	}
+	msg := []byte{kind}
+	msg = binary.BigEndian.AppendUint16(msg, uint16(width))
+	msg = binary.BigEndian.AppendUint16(msg, uint16(height))
+	changed := false
+	for row := 0; row < height; row++ {
+		if !full && rowEqual(prev, fb, row) {
+			continue
+		}
+		changed = true
+		if !full {
+			msg = binary.BigEndian.AppendUint16(msg, uint16(row))
+		}
+		for col := 0; col < width; col++ {
+			msg = append(msg, fb.Pixel(row, col))
+		}
+	}
+	if !full && !changed {
+		return nil
+	}
+	return msg
+}
+
+// rowEqual ... Returns true if a row of two framebuffers of the same size has the same pixels
+func rowEqual(a, b *framebuffer.Framebuffer, row int) bool {
+	for col := 0; col < a.Width(); col++ {
+		if a.Pixel(row, col) != b.Pixel(row, col) {
+			return false
+		}
+	}
+	return true
+}
+
+// encodeSound ... Returns the S message for the beep starting or stopping
+func encodeSound(on bool) []byte {
+	if on {
+		return []byte{msgSound, 1}
+	}
+	return []byte{msgSound, 0}
+}
+
+// encodePattern ... Returns the A message for an audio pattern played at rate
+func encodePattern(pattern [16]byte, rate float64) []byte {
+	msg := append([]byte{msgPattern}, pattern[:]...)
+	return binary.BigEndian.AppendUint32(msg, math.Float32bits(float32(rate)))
+}
+
+// decodeInput ... Decodes a message from the page. returns an error if it isnt one
+func decodeInput(msg []byte) (input, error) {
+	if len(msg) == 0 {
+		return input{}, fmt.Errorf("error in webio/decodeInput(): empty message")
+	}
+	switch msg[0] {
+	case msgKey:
+		if len(msg) != 3 || msg[1] > 0xF {
+			return input{}, fmt.Errorf("error in webio/decodeInput(): invalid key message %v", msg)
+		}
+		return input{kind: msgKey, key: msg[1], down: msg[2] != 0}, nil
+	case msgHotkey:
+		if len(msg) == 2 {
+			if hotkey, ok := hotkeyCodes[msg[1]]; ok {
+				return input{kind: msgHotkey, hotkey: hotkey}, nil
+			}
+		}
+		return input{}, fmt.Errorf("error in webio/decodeInput(): invalid hotkey message %v", msg)
+	case msgQuit:
+		return input{kind: msgQuit}, nil
+	}
+	return input{}, fmt.Errorf("error in webio/decodeInput(): unknown message type %q", msg[0])
+}
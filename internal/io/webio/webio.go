@@ -0,0 +1,348 @@
+package webio
+
+import (
+	_ "embed"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/TH3-F001/GoChip-8/chip8/internal/framebuffer"
+	"github.com/TH3-F001/GoChip-8/chip8/internal/io"
+	"github.com/TH3-F001/GoChip-8/chip8/internal/palette"
+)
+
+// defaultAddress ... Where the page is served when no address is given
+const defaultAddress = "localhost:8080"
+
+// sendQueue ... How many messages can wait to be sent to a page before it is considered too slow, and frames are dropped until it catches up
+const sendQueue = 16
+
+// flushTimeout ... How long Terminate waits for the pages to be told the emulator is exiting
+const flushTimeout = time.Second
+
+// page ... The page served to the browser. It draws frames on a canvas, plays the beep with WebAudio, and sends keys back. see protocol.go
+//
+//go:embed index.html
+var page []byte
+
+// client ... A page connected over a WebSocket. send is its queue of messages, written to the page by a goroutine of its own.
+// resync is true when messages had to be dropped because the queue was full, so the page needs the whole frame again once it has caught up.
+// held holds whether the page is holding each hex key down. Both are guarded by WebIO.mu
+type client struct {
+	conn   *conn
+	send   chan []byte
+	resync bool
+	held   [16]bool
+}
+
+// WebIO ... Serves the emulator to a browser over HTTP, streaming frames and the beep over a WebSocket and taking keys back from it. Should be instantiated using webio.New()
+// Any number of pages can be open at once. They are all shown the same frames, and all of them drive the same keypad
+// palette holds the hex RGB colour of each combination of lit bitplanes (none, first, second, both). listener and server serve the page and its WebSocket
+// keys holds whether each hex key is held down on any page, and is only released once no connected page is holding it
+// mu guards everything below it. frame is a copy of the last frame presented, nil until there is one, and sound is true while the beep is playing.
+// pattern and rate are the audio pattern the beep plays and its rate in Hz
+// clients holds every connected page, and writers counts their goroutines. closed is true once Terminate has been called, after which pages are turned away
+// hotkeyCh and termCh are where hotkeys and termination requests are sent. nil until SetHotkeyChannel and ListenForControl are called
+type WebIO struct {
+	palette  [4]uint32
+	listener net.Listener
+	server   *http.Server
+	keys     *io.KeyState
+
+	mu       sync.Mutex
+	frame    *framebuffer.Framebuffer
+	sound    bool
+	pattern  [16]byte
+	rate     float64
+	clients  map[*client]struct{}
+	writers  sync.WaitGroup
+	closed   bool
+	hotkeyCh chan<- io.Hotkey
+	termCh   chan<- bool
+}
+
+// New ... Starts serving the page on addr, or defaultAddress if it is empty. The server has no authentication, so addr should only be reachable from this machine.
+// fg and bg expect colors as hexcodes. custom optionally overrides the colours of each combination of lit XO-CHIP bitplanes. see palette.Resolve
+func New(addr string, fgColor, bgColor uint32, custom []uint32) (*WebIO, error) {
+	if addr == "" {
+		addr = defaultAddress
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("error in webio/New(): %w", err)
+	}
+	w := WebIO{
+		palette:  palette.Resolve(fgColor, bgColor, custom),
+		listener: listener,
+		keys:     io.NewKeyState(0),
+		clients:  make(map[*client]struct{}),
+		pattern:  io.BeepPattern,
+		rate:     io.BeepRate,
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", w.servePage)
+	mux.HandleFunc("/ws", w.serveSocket)
+	w.server = &http.Server{Handler: mux}
+	go w.server.Serve(listener)
+	return &w, nil
+}
+
+// URL ... Returns the address of the page
+func (w *WebIO) URL() string {
+	return "http://" + w.listener.Addr().String() + "/"
+}
+
+// #region Server
+
+// servePage ... Serves the page at / and nothing anywhere else
+func (w *WebIO) servePage(rw http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(rw, r)
+		return
+	}
+	rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+	rw.Write(page)
+}
+
+// serveSocket ... Upgrades a page's request to a WebSocket, sends it the palette and the current frame and beep, and then handles its messages until it disconnects
+func (w *WebIO) serveSocket(rw http.ResponseWriter, r *http.Request) {
+	c, err := upgrade(rw, r)
+	if err != nil {
+		return
+	}
+	cl := &client{conn: c, send: make(chan []byte, sendQueue)}
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		c.close()
+		return
+	}
+	w.clients[cl] = struct{}{}
+	w.writers.Add(1)
+	w.queue(cl, encodePalette(w.palette))
+	w.sync(cl)
+	w.mu.Unlock()
+	go w.write(cl)
+
+	for {
+		opcode, msg, err := c.readMessage()
+		if err != nil {
+			break
+		}
+		if opcode != opBinary {
+			continue
+		}
+		// messages that arent understood are dropped, there is no one to report them to
+		if in, err := decodeInput(msg); err == nil {
+			w.handle(cl, in)
+		}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, ok := w.clients[cl]; ok {
+		delete(w.clients, cl)
+		close(cl.send)
+	}
+	// the page cant release the keys it was holding anymore. keys other pages are holding stay down
+	for key, held := range cl.held {
+		if held {
+			w.release(cl, byte(key))
+		}
+	}
+}
+
+// write ... Writes a page's messages to it until its queue is closed, and then closes its connection. Runs on a goroutine of its own
+// Once a page that needs resyncing has been sent everything queued, it is sent the whole frame
+func (w *WebIO) write(cl *client) {
+	defer w.writers.Done()
+	for msg := range cl.send {
+		if err := cl.conn.writeMessage(msg); err != nil {
+			// closing the connection ends the read loop in serveSocket, which closes the queue
+			cl.conn.netConn.Close()
+			for range cl.send {
+			}
+			return
+		}
+		if len(cl.send) == 0 {
+			w.mu.Lock()
+			if _, ok := w.clients[cl]; ok && cl.resync {
+				w.sync(cl)
+			}
+			w.mu.Unlock()
+		}
+	}
+	cl.conn.close()
+}
+
+// queue ... Queues a message for a page. If its queue is full the message is dropped, and the page is resynced with the next frame. mu must be held
+func (w *WebIO) queue(cl *client, msg []byte) {
+	select {
+	case cl.send <- msg:
+	default:
+		cl.resync = true
+	}
+}
+
+// sync ... Queues the whole of the current frame and the beep's state and pattern for a page. mu must be held
+func (w *WebIO) sync(cl *client) {
+	cl.resync = false
+	if w.frame != nil {
+		w.queue(cl, encodeFrame(nil, w.frame))
+	}
+	w.queue(cl, encodePattern(w.pattern, w.rate))
+	w.queue(cl, encodeSound(w.sound))
+}
+
+// broadcast ... Queues a message for every page. Pages that need resyncing are skipped, since they will be sent the whole frame anyway. mu must be held
+func (w *WebIO) broadcast(msg []byte) {
+	for cl := range w.clients {
+		if !cl.resync {
+			w.queue(cl, msg)
+		}
+	}
+}
+
+// release ... Lets go of a key held by a page, releasing it on the keypad unless another connected page is holding it too. mu must be held
+func (w *WebIO) release(cl *client, key byte) {
+	cl.held[key] = false
+	for other := range w.clients {
+		if other.held[key] {
+			return
+		}
+	}
+	w.keys.Release(key)
+}
+
+// handle ... Acts on a message from a page
+func (w *WebIO) handle(cl *client, in input) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	switch in.kind {
+	case msgKey:
+		if in.down {
+			cl.held[in.key] = true
+			w.keys.Press(in.key)
+		} else {
+			w.release(cl, in.key)
+		}
+	case msgHotkey:
+		if w.hotkeyCh != nil {
+			select {
+			case w.hotkeyCh <- in.hotkey:
+			default:
+			}
+		}
+	case msgQuit:
+		if termCh := w.termCh; termCh != nil {
+			go func() { termCh <- true }()
+		}
+	}
+}
+
+//#endregion
+
+// #region Display and Audio
+
+// Present ... Sends the rows of a completed frame that changed since the last one to every page
+func (w *WebIO) Present(fb *framebuffer.Framebuffer) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	msg := encodeFrame(w.frame, fb)
+	if msg == nil {
+		return nil
+	}
+	if w.frame != nil && w.frame.Width() == fb.Width() && w.frame.Height() == fb.Height() {
+		w.frame.CopyFrom(fb)
+	} else {
+		w.frame = fb.Clone()
+	}
+	w.broadcast(msg)
+	return nil
+}
+
+// Sound ... Tells every page to start or stop the beep
+func (w *WebIO) Sound(on bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if on == w.sound {
+		return
+	}
+	w.sound = on
+	w.broadcast(encodeSound(on))
+}
+
+// SetPattern ... Tells every page the XO-CHIP audio pattern the beep plays, and the rate in Hz its bits are played at. A pattern of all zeros goes back to the plain beep
+func (w *WebIO) SetPattern(pattern [16]byte, rate float64) {
+	if pattern == [16]byte{} {
+		pattern, rate = io.BeepPattern, io.BeepRate
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if pattern == w.pattern && rate == w.rate {
+		return
+	}
+	w.pattern, w.rate = pattern, rate
+	w.broadcast(encodePattern(pattern, rate))
+}
+
+//#endregion
+
+// #region Keypad
+
+// ListenWait ... Returns the key pressed since the last call, or io.NoKey if there isnt one. Fx0A repeats until it gets a key, so never blocks.
+// Keys pressed before the first call of a wait dont count, so a wait is only ended by a fresh press
+func (w *WebIO) ListenWait() (byte, error) {
+	return w.keys.ListenWait()
+}
+
+// ListenNow ... Returns the last key pressed if it is still held down, or any other key that is. io.NoKey if none are
+func (w *WebIO) ListenNow() (byte, error) {
+	return w.keys.ListenNow()
+}
+
+// KeyDown ... Returns true if the given hex key is held down on any page
+func (w *WebIO) KeyDown(key byte) bool {
+	return w.keys.KeyDown(key)
+}
+
+// SetHotkeyChannel ... Sets the channel that hotkeys (F5: save state, F6/F7: previous/next slot, F8: load state, F9: start/stop recording, F12: screenshot, Backspace: rewind) are sent to
+func (w *WebIO) SetHotkeyChannel(hotkeyCh chan<- io.Hotkey) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.hotkeyCh = hotkeyCh
+}
+
+// ListenForControl ... Sends to termCh when Escape is pressed on a page. Pages are already handled by the server's goroutines, so doesnt start one of its own
+func (w *WebIO) ListenForControl(termCh chan<- bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.termCh = termCh
+}
+
+//#endregion
+
+// Terminate ... Tells every page the emulator is exiting, waits up to flushTimeout for them to be told, and stops the server
+func (w *WebIO) Terminate() {
+	w.mu.Lock()
+	w.closed = true
+	for cl := range w.clients {
+		w.queue(cl, []byte{msgExit})
+		delete(w.clients, cl)
+		close(cl.send)
+	}
+	w.mu.Unlock()
+
+	flushed := make(chan struct{})
+	go func() {
+		w.writers.Wait()
+		close(flushed)
+	}()
+	select {
+	case <-flushed:
+	case <-time.After(flushTimeout):
+	}
+	w.server.Close()
+}
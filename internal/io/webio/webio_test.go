@@ -0,0 +1,218 @@
+package webio
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/TH3-F001/GoChip-8/chip8/internal/framebuffer"
+	"github.com/TH3-F001/GoChip-8/chip8/internal/io"
+)
+
+// newLocal ... Creates a WebIO serving on a free loopback port, shut down when the test ends
+func newLocal(t *testing.T) *WebIO {
+	t.Helper()
+	w, err := New("127.0.0.1:0", 0xFFFFFF, 0x000000, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { w.server.Close() })
+	return w
+}
+
+// handshake ... Opens a WebSocket to w, sending host and origin (if not empty) in the handshake. returns the response, and the client side of the connection if it was upgraded
+func handshake(t *testing.T, w *WebIO, host, origin string) (*http.Response, *conn) {
+	t.Helper()
+	netConn, err := net.Dial("tcp", w.listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { netConn.Close() })
+	netConn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	req, err := http.NewRequest(http.MethodGet, "http://"+w.listener.Addr().String()+"/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Host = host
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	if err := req.Write(netConn); err != nil {
+		t.Fatal(err)
+	}
+	br := bufio.NewReader(netConn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		return resp, nil
+	}
+	if accept := resp.Header.Get("Sec-WebSocket-Accept"); accept != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("Sec-WebSocket-Accept = %q, want the RFC 6455 example's", accept)
+	}
+	return resp, newConn(netConn, bufio.NewReadWriter(br, bufio.NewWriter(netConn)), true)
+}
+
+// read ... Reads the next message from the server, failing the test if it isnt want
+func read(t *testing.T, c *conn, what string, want []byte) {
+	t.Helper()
+	opcode, msg, err := c.readMessage()
+	if err != nil {
+		t.Fatalf("reading %s: %v", what, err)
+	}
+	if opcode != opBinary || !bytes.Equal(msg, want) {
+		t.Fatalf("%s = %d % x, want a binary % x", what, opcode, msg, want)
+	}
+}
+
+// waitFor ... Fails the test if cond doesnt become true within a second
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); !cond(); time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+	}
+}
+
+func TestPageIsSentPaletteAndFrames(t *testing.T) {
+	w := newLocal(t)
+	fb, err := framebuffer.New(32, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Present(fb)
+
+	_, c := handshake(t, w, w.listener.Addr().String(), "http://"+w.listener.Addr().String())
+	if c == nil {
+		t.Fatal("handshake refused")
+	}
+	read(t, c, "palette", []byte{'P', 0x00, 0x00, 0x00, 0xFF, 0xFF, 0xFF, 0xAA, 0xAA, 0xAA, 0x55, 0x55, 0x55})
+	read(t, c, "first frame", append([]byte{'F', 0, 64, 0, 32}, make([]byte, 64*32)...))
+	read(t, c, "pattern", encodePattern(io.BeepPattern, io.BeepRate))
+	read(t, c, "sound", []byte{'S', 0})
+
+	// only the row that changed is sent, with both bitplanes of its lit pixel
+	next := fb.Clone()
+	next.Set(0, 3, 5, true)
+	next.Set(1, 3, 5, true)
+	w.Present(next)
+	row := make([]byte, 64)
+	row[5] = 3
+	read(t, c, "diff", append([]byte{'D', 0, 64, 0, 32, 0, 3}, row...))
+
+	// a frame that didnt change isnt sent at all, so the next message is the beep
+	w.Present(next)
+	w.Sound(true)
+	read(t, c, "sound", []byte{'S', 1})
+}
+
+func TestPageDrivesKeypad(t *testing.T) {
+	w := newLocal(t)
+	_, c := handshake(t, w, w.listener.Addr().String(), "")
+	if c == nil {
+		t.Fatal("handshake refused")
+	}
+
+	c.writeMessage([]byte{'K', 0x5, 1})
+	waitFor(t, "key 5 to be held", func() bool { return w.KeyDown(0x5) })
+	if w.KeyDown(0x4) {
+		t.Error("KeyDown(0x4) = true, want only 0x5 held")
+	}
+	c.writeMessage([]byte{'K', 0x5, 0})
+	waitFor(t, "key 5 to be released", func() bool { return !w.KeyDown(0x5) })
+
+	// keys held by a page that disconnects are released, since it cant release them itself anymore
+	c.writeMessage([]byte{'K', 0xA, 1})
+	waitFor(t, "key A to be held", func() bool { return w.KeyDown(0xA) })
+	c.close()
+	waitFor(t, "key A to be released", func() bool { return !w.KeyDown(0xA) })
+}
+
+func TestPagesHoldKeysTogether(t *testing.T) {
+	w := newLocal(t)
+	_, first := handshake(t, w, w.listener.Addr().String(), "")
+	_, second := handshake(t, w, w.listener.Addr().String(), "")
+	if first == nil || second == nil {
+		t.Fatal("handshake refused")
+	}
+
+	// a key held on both pages stays down until both have let go of it
+	first.writeMessage([]byte{'K', 0x5, 1})
+	second.writeMessage([]byte{'K', 0x5, 1})
+	second.writeMessage([]byte{'K', 0x7, 1})
+	waitFor(t, "keys 5 and 7 to be held", func() bool { return w.KeyDown(0x5) && w.KeyDown(0x7) })
+	first.writeMessage([]byte{'K', 0x5, 0})
+	first.writeMessage([]byte{'K', 0x7, 0})
+	first.writeMessage([]byte{'K', 0xA, 1})
+	waitFor(t, "key A to be held", func() bool { return w.KeyDown(0xA) })
+	if !w.KeyDown(0x5) || !w.KeyDown(0x7) {
+		t.Error("keys still held on the second page were released by the first")
+	}
+
+	// a page disconnecting only releases the keys it was holding
+	first.close()
+	waitFor(t, "key A to be released", func() bool { return !w.KeyDown(0xA) })
+	if !w.KeyDown(0x5) || !w.KeyDown(0x7) {
+		t.Error("keys held on the second page were released when the first disconnected")
+	}
+	second.close()
+	waitFor(t, "keys 5 and 7 to be released", func() bool { return !w.KeyDown(0x5) && !w.KeyDown(0x7) })
+}
+
+func TestHandshakeRefusesOtherSites(t *testing.T) {
+	w := newLocal(t)
+	_, port, _ := net.SplitHostPort(w.listener.Addr().String())
+	tests := []struct {
+		name       string
+		host       string
+		origin     string
+		wantStatus int
+	}{
+		{name: "loopback address", host: "127.0.0.1:" + port, origin: "http://127.0.0.1:" + port, wantStatus: http.StatusSwitchingProtocols},
+		{name: "localhost", host: "localhost:" + port, origin: "http://localhost:" + port, wantStatus: http.StatusSwitchingProtocols},
+		{name: "ipv6 loopback", host: "[::1]:" + port, origin: "http://[::1]:" + port, wantStatus: http.StatusSwitchingProtocols},
+		{name: "no origin, as from a non-browser client", host: "127.0.0.1:" + port, wantStatus: http.StatusSwitchingProtocols},
+		{name: "another site's page", host: "127.0.0.1:" + port, origin: "http://evil.example", wantStatus: http.StatusForbidden},
+		{name: "another site's name pointed at localhost", host: "evil.example:" + port, origin: "http://evil.example:" + port, wantStatus: http.StatusForbidden},
+		{name: "a lan address", host: "192.168.1.2:" + port, origin: "http://192.168.1.2:" + port, wantStatus: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, c := handshake(t, w, tt.host, tt.origin)
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("handshake status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if c != nil {
+				c.close()
+			}
+		})
+	}
+}
+
+func TestTerminateTellsPages(t *testing.T) {
+	w := newLocal(t)
+	_, c := handshake(t, w, w.listener.Addr().String(), "")
+	if c == nil {
+		t.Fatal("handshake refused")
+	}
+	read(t, c, "palette", encodePalette(w.palette))
+	read(t, c, "pattern", encodePattern(io.BeepPattern, io.BeepRate))
+	read(t, c, "sound", []byte{'S', 0})
+
+	// Terminate returns rather than exiting, leaving the exit to the caller
+	w.Terminate()
+	read(t, c, "exit", []byte{msgExit})
+	if _, err := http.Get(w.URL()); err == nil {
+		t.Error("the page is still served after Terminate()")
+	}
+}
@@ -0,0 +1,252 @@
+package webio
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// A minimal WebSocket (RFC 6455) implementation, only as much of it as WebIO needs: the server side of the handshake, and reading and writing
+// whole messages. Connections are symmetric apart from masking, so the same conn works as an in-process client when created with client set
+
+// websocketGUID ... Appended to the client's key to compute the handshake's Sec-WebSocket-Accept
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// maxMessage ... The largest message read, in bytes. Clients only ever send a few bytes at a time
+const maxMessage = 1 << 16
+
+// Opcodes ... The frame types of the WebSocket protocol
+const (
+	opContinuation byte = 0x0
+	opText         byte = 0x1
+	opBinary       byte = 0x2
+	opClose        byte = 0x8
+	opPing         byte = 0x9
+	opPong         byte = 0xA
+)
+
+// errMessageTooBig ... Returned by readMessage when a message is bigger than maxMessage
+var errMessageTooBig = errors.New("websocket message too big")
+
+// conn ... A WebSocket connection. Should be instantiated using upgrade(), or newConn() for the client side
+// rw is the buffered connection, and client is true if this is the client side, which masks the frames it sends
+// wmu serialises writes, since control frames are answered by the reading goroutine while another one writes messages
+type conn struct {
+	netConn net.Conn
+	rw      *bufio.ReadWriter
+	client  bool
+
+	wmu sync.Mutex
+}
+
+// acceptKey ... Returns the Sec-WebSocket-Accept for a Sec-WebSocket-Key
+func acceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// headerContains ... Returns true if the comma separated header has the given token, ignoring case
+func headerContains(header http.Header, name, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, field := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(field), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// loopbackHost ... Returns true if the Host of a request names this machine: localhost or a loopback address, with or without a port
+func loopbackHost(host string) bool {
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
+	}
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// upgrade ... Completes the handshake of a WebSocket request and takes over its connection. Requests from other sites are refused,
+// since any page the browser has open could otherwise connect to localhost. So are requests for any host but this machine, since a site
+// can point a name of its own at 127.0.0.1 and then pass the Origin check as that name. On error, the response has already been written
+func upgrade(w http.ResponseWriter, r *http.Request) (*conn, error) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if r.Method != http.MethodGet || !headerContains(r.Header, "Connection", "upgrade") || !headerContains(r.Header, "Upgrade", "websocket") || key == "" {
+		http.Error(w, "expected a websocket handshake", http.StatusBadRequest)
+		return nil, fmt.Errorf("error in webio/upgrade(): not a websocket handshake")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "unsupported websocket version", http.StatusUpgradeRequired)
+		return nil, fmt.Errorf("error in webio/upgrade(): unsupported websocket version %q", r.Header.Get("Sec-WebSocket-Version"))
+	}
+	if !loopbackHost(r.Host) {
+		http.Error(w, "websocket only served to localhost", http.StatusForbidden)
+		return nil, fmt.Errorf("error in webio/upgrade(): refused websocket for host %q", r.Host)
+	}
+	if origin := r.Header.Get("Origin"); origin != "" && origin != "http://"+r.Host {
+		http.Error(w, "cross origin websocket refused", http.StatusForbidden)
+		return nil, fmt.Errorf("error in webio/upgrade(): refused websocket from origin %q", origin)
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "websocket not supported", http.StatusInternalServerError)
+		return nil, fmt.Errorf("error in webio/upgrade(): connection cant be hijacked")
+	}
+	netConn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, fmt.Errorf("error in webio/upgrade(): %w", err)
+	}
+	rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+	rw.WriteString("Sec-WebSocket-Accept: " + acceptKey(key) + "\r\n\r\n")
+	if err := rw.Flush(); err != nil {
+		netConn.Close()
+		return nil, fmt.Errorf("error in webio/upgrade(): %w", err)
+	}
+	return newConn(netConn, rw, false), nil
+}
+
+// newConn ... Creates a conn over a connection that has already completed the handshake. rw must read from and write to netConn
+func newConn(netConn net.Conn, rw *bufio.ReadWriter, client bool) *conn {
+	return &conn{netConn: netConn, rw: rw, client: client}
+}
+
+// readMessage ... Reads the next text or binary message, joining fragmented messages back together. Pings are answered along the way.
+// returns io.EOF once the other side has closed the connection
+func (c *conn) readMessage() (byte, []byte, error) {
+	var opcode byte
+	var message []byte
+	for {
+		fin, op, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+		switch op {
+		case opPing:
+			if err := c.writeFrame(opPong, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case opPong:
+			continue
+		case opClose:
+			c.writeFrame(opClose, nil)
+			return 0, nil, io.EOF
+		case opContinuation:
+			if opcode == 0 {
+				return 0, nil, fmt.Errorf("error in webio/conn.readMessage(): continuation without a message to continue")
+			}
+		default:
+			if opcode != 0 {
+				return 0, nil, fmt.Errorf("error in webio/conn.readMessage(): new message before the last one finished")
+			}
+			opcode = op
+		}
+		if len(message)+len(payload) > maxMessage {
+			return 0, nil, fmt.Errorf("error in webio/conn.readMessage(): %w", errMessageTooBig)
+		}
+		message = append(message, payload...)
+		if fin {
+			return opcode, message, nil
+		}
+	}
+}
+
+// readFrame ... Reads a single frame, unmasking its payload if it is masked
+func (c *conn) readFrame() (bool, byte, []byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.rw, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin, opcode := header[0]&0x80 != 0, header[0]&0x0F
+	masked, length := header[1]&0x80 != 0, uint64(header[1]&0x7F)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.rw, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.rw, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if length > maxMessage {
+		return false, 0, nil, fmt.Errorf("error in webio/conn.readFrame(): %w", errMessageTooBig)
+	}
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.rw, mask[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.rw, payload); err != nil {
+		return false, 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return fin, opcode, payload, nil
+}
+
+// writeMessage ... Writes data as a single binary message
+func (c *conn) writeMessage(data []byte) error {
+	return c.writeFrame(opBinary, data)
+}
+
+// writeFrame ... Writes a single unfragmented frame. Frames sent by the client side are masked, as the protocol requires
+func (c *conn) writeFrame(opcode byte, payload []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	header := []byte{0x80 | opcode, 0}
+	switch length := len(payload); {
+	case length < 126:
+		header[1] = byte(length)
+	case length <= 0xFFFF:
+		header[1] = 126
+		header = binary.BigEndian.AppendUint16(header, uint16(length))
+	default:
+		header[1] = 127
+		header = binary.BigEndian.AppendUint64(header, uint64(length))
+	}
+	if c.client {
+		var mask [4]byte
+		rand.Read(mask[:])
+		header[1] |= 0x80
+		header = append(header, mask[:]...)
+		masked := make([]byte, len(payload))
+		for i := range payload {
+			masked[i] = payload[i] ^ mask[i%4]
+		}
+		payload = masked
+	}
+	c.rw.Write(header)
+	c.rw.Write(payload)
+	return c.rw.Flush()
+}
+
+// close ... Closes the connection, telling the other side first if it is still there
+func (c *conn) close() error {
+	c.writeFrame(opClose, nil)
+	return c.netConn.Close()
+}
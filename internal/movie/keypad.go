@@ -0,0 +1,150 @@
+package movie
+
+import (
+	"github.com/TH3-F001/GoChip-8/chip8/internal/io"
+)
+
+// #region Recorder
+
+// Recorder ... Wraps an io.Keypad and records every change to its state into a Movie. Should be instantiated using movie.NewRecorder()
+// The keypad is latched once per frame: the first key query of a frame asks the keypad for the key it reports and every key held down,
+// and the rest of the frame sees the same state. That way a recording only needs to store the keypad once per frame rather than per instruction, and plays back identically
+// frame is the frame currently being emulated, and latched is true once its state has been read into key and keys
+// last and lastKeys are the state stored by the most recent Event
+type Recorder struct {
+	io.Keypad
+	movie    *Movie
+	frame    uint64
+	latched  bool
+	key      byte
+	keys     uint16
+	last     byte
+	lastKeys uint16
+}
+
+// NewRecorder ... Creates a Recorder that records the keypresses of keypad into m
+func NewRecorder(m *Movie, keypad io.Keypad) *Recorder {
+	return &Recorder{
+		Keypad: keypad,
+		movie:  m,
+		key:    NoKey,
+		last:   NoKey,
+	}
+}
+
+// BeginFrame ... Unlatches the keypad so the next key query reads from it, then passes the call on to the keypad if it is an io.FrameIO
+func (r *Recorder) BeginFrame(frame uint64) {
+	r.frame = frame
+	r.latched = false
+	if framer, ok := r.Keypad.(io.FrameIO); ok {
+		framer.BeginFrame(frame)
+	}
+}
+
+// ListenNow ... Returns the key latched for this frame, reading the keypad first if this is the frame's first key query
+func (r *Recorder) ListenNow() (byte, error) {
+	if !r.latched {
+		if err := r.latch(r.Keypad.ListenNow); err != nil {
+			return NoKey, err
+		}
+	}
+	return r.key, nil
+}
+
+// ListenWait ... Takes a key from the keypad if this is the frame's first key query, otherwise returns the key latched for this frame without blocking
+func (r *Recorder) ListenWait() (byte, error) {
+	if !r.latched {
+		if err := r.latch(r.Keypad.ListenWait); err != nil {
+			return NoKey, err
+		}
+	}
+	return r.key, nil
+}
+
+// KeyDown ... Returns true if the given hex key was held down when this frame was latched, reading the keypad first if this is the frame's first key query
+func (r *Recorder) KeyDown(key byte) bool {
+	if !r.latched {
+		r.latch(r.Keypad.ListenNow)
+	}
+	return r.keys&keyBit(key) != 0
+}
+
+// latch ... Holds the key listen returns and every key held down for the rest of the frame, and records an Event if they differ from the last recorded state.
+// Keypads that dont track held keys only hold the key they report. If listen fails the frame is latched with no keys down, so that playback sees the same
+// returns listen's error
+func (r *Recorder) latch(listen func() (byte, error)) error {
+	key, err := listen()
+	if err != nil || key > 0xF {
+		key = NoKey
+	}
+	var keys uint16
+	if state, ok := r.Keypad.(io.KeyStateIO); ok && err == nil {
+		for k := byte(0); k <= 0xF; k++ {
+			if state.KeyDown(k) {
+				keys |= keyBit(k)
+			}
+		}
+	} else {
+		keys = keyBit(key)
+	}
+
+	r.key, r.keys = key, keys
+	r.latched = true
+	if key != r.last || keys != r.lastKeys {
+		r.movie.Events = append(r.movie.Events, Event{Frame: r.frame, Key: key, Keys: keys})
+		r.last, r.lastKeys = key, keys
+	}
+	return err
+}
+
+//#endregion
+
+// #region Player
+
+// Player ... Stands in for an io.Keypad, answering every key query from a Movie instead of the keyboard. Should be instantiated using movie.NewPlayer()
+// The keypad it replaces is still told when frames begin
+// next is the index of the next Event to apply, key is the key reported for the current frame, and keys holds the keys held down during it
+type Player struct {
+	io.Keypad
+	movie *Movie
+	next  int
+	key   byte
+	keys  uint16
+}
+
+// NewPlayer ... Creates a Player that plays m back in place of keypad
+func NewPlayer(m *Movie, keypad io.Keypad) *Player {
+	return &Player{
+		Keypad: keypad,
+		movie:  m,
+		key:    NoKey,
+	}
+}
+
+// BeginFrame ... Applies every Event up to and including frame, then passes the call on to the keypad if it is an io.FrameIO
+func (p *Player) BeginFrame(frame uint64) {
+	for p.next < len(p.movie.Events) && p.movie.Events[p.next].Frame <= frame {
+		p.key, p.keys = p.movie.Events[p.next].Key, p.movie.Events[p.next].Keys
+		p.next++
+	}
+	if framer, ok := p.Keypad.(io.FrameIO); ok {
+		framer.BeginFrame(frame)
+	}
+}
+
+// ListenNow ... Returns the key the movie reports for the current frame
+func (p *Player) ListenNow() (byte, error) {
+	return p.key, nil
+}
+
+// ListenWait ... Returns the key the movie reports for the current frame. never blocks
+func (p *Player) ListenWait() (byte, error) {
+	return p.key, nil
+}
+
+// KeyDown ... Returns true if the movie holds the given hex key down for the current frame
+func (p *Player) KeyDown(key byte) bool {
+	return p.keys&keyBit(key) != 0
+}
+
+//#endregion
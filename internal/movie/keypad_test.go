@@ -0,0 +1,109 @@
+package movie
+
+import (
+	"errors"
+	"testing"
+)
+
+// fakeKeypad ... A keypad holding the keys in held down, and reporting last. Fails every query with err if it is set, and counts the queries it is asked
+type fakeKeypad struct {
+	held    uint16
+	last    byte
+	err     error
+	queries int
+}
+
+func (f *fakeKeypad) ListenWait() (byte, error) { return f.ListenNow() }
+
+func (f *fakeKeypad) ListenNow() (byte, error) {
+	f.queries++
+	if f.err != nil {
+		return 0, f.err
+	}
+	return f.last, nil
+}
+
+func (f *fakeKeypad) KeyDown(key byte) bool { return f.held&keyBit(key) != 0 }
+
+func TestRecordAndPlayBackHeldKeys(t *testing.T) {
+	// the keys held each frame. frame 2 holds the same keys as frame 1, so needs no Event of its own
+	frames := []struct {
+		held uint16
+		last byte
+	}{
+		{0, NoKey},
+		{keyBit(0x5) | keyBit(0x7), 0x7},
+		{keyBit(0x5) | keyBit(0x7), 0x7},
+		{keyBit(0x5), 0x5},
+		{0, NoKey},
+	}
+	m := &Movie{}
+	keypad := &fakeKeypad{}
+	recorder := NewRecorder(m, keypad)
+	for frame, state := range frames {
+		keypad.held, keypad.last = state.held, state.last
+		recorder.BeginFrame(uint64(frame))
+		// the first query latches the frame, so the keypad changing part way through a frame isnt seen until the next
+		if got := recorder.KeyDown(0x5); got != (state.held&keyBit(0x5) != 0) {
+			t.Errorf("frame %d: KeyDown(0x5) = %v", frame, got)
+		}
+		keypad.held, keypad.last = 0xFFFF, 0x1
+		if key, _ := recorder.ListenNow(); key != state.last {
+			t.Errorf("frame %d: ListenNow() = %#x, want %#x", frame, key, state.last)
+		}
+	}
+	want := []Event{
+		{Frame: 1, Key: 0x7, Keys: keyBit(0x5) | keyBit(0x7)},
+		{Frame: 3, Key: 0x5, Keys: keyBit(0x5)},
+		{Frame: 4, Key: NoKey, Keys: 0},
+	}
+	if len(m.Events) != len(want) {
+		t.Fatalf("recorded %+v, want %+v", m.Events, want)
+	}
+	for i := range want {
+		if m.Events[i] != want[i] {
+			t.Fatalf("recorded %+v, want %+v", m.Events, want)
+		}
+	}
+
+	player := NewPlayer(m, &fakeKeypad{held: 0xFFFF, last: 0x1})
+	for frame, state := range frames {
+		player.BeginFrame(uint64(frame))
+		for key := byte(0); key <= 0xF; key++ {
+			if got := player.KeyDown(key); got != (state.held&keyBit(key) != 0) {
+				t.Errorf("frame %d: played back KeyDown(%#x) = %v", frame, key, got)
+			}
+		}
+		if key, _ := player.ListenNow(); key != state.last {
+			t.Errorf("frame %d: played back ListenNow() = %#x, want %#x", frame, key, state.last)
+		}
+	}
+}
+
+func TestRecorderLatchesFailedQueries(t *testing.T) {
+	m := &Movie{}
+	keypad := &fakeKeypad{held: keyBit(0x5), last: 0x5}
+	recorder := NewRecorder(m, keypad)
+	recorder.BeginFrame(0)
+	recorder.ListenNow()
+
+	keypad.err = errors.New("keyboard gone")
+	recorder.BeginFrame(1)
+	if _, err := recorder.ListenNow(); !errors.Is(err, keypad.err) {
+		t.Fatalf("ListenNow() error = %v, want the keypad's", err)
+	}
+	// the rest of the frame sees no keys, as playback will, rather than asking the keypad again
+	keypad.err = nil
+	if recorder.KeyDown(0x5) {
+		t.Error("KeyDown(0x5) = true after the frame's query failed")
+	}
+	if key, err := recorder.ListenNow(); key != NoKey || err != nil {
+		t.Errorf("ListenNow() = %#x, %v after the frame's query failed, want NoKey", key, err)
+	}
+	if keypad.queries != 2 {
+		t.Errorf("keypad queried %d times, want once per frame", keypad.queries)
+	}
+	if last := m.Events[len(m.Events)-1]; last != (Event{Frame: 1, Key: NoKey}) {
+		t.Errorf("last Event = %+v, want frame 1 recorded with no keys", last)
+	}
+}
@@ -0,0 +1,152 @@
+package movie
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/BurntSushi/toml"
+	"github.com/TH3-F001/GoChip-8/chip8/internal/chip8"
+	"github.com/TH3-F001/GoChip-8/chip8/internal/config"
+)
+
+// Version ... The version of the movie format written by Save. Load rejects any other version
+const Version = 1
+
+// NoKey ... The keypad state recorded while no key is pressed
+const NoKey byte = 0xFF
+
+var (
+	// ErrROMMismatch ... Returned when a movie is played back against a different ROM to the one it was recorded with
+	ErrROMMismatch = errors.New("movie was recorded with a different ROM")
+	// ErrDesync ... Returned by Verify when playback doesnt end on the same display the recording did
+	ErrDesync = errors.New("movie playback desynced")
+)
+
+// Event ... A change to the keypad state. Key is the hex key reported from Frame onwards, or NoKey,
+// and Keys holds every hex key held down from Frame onwards, with bit n set while key n is down
+type Event struct {
+	Frame uint64
+	Key   byte
+	Keys  uint16
+}
+
+// keyBit ... Returns the Keys bit of a hex key, or 0 for NoKey
+func keyBit(key byte) uint16 {
+	if key > 0xF {
+		return 0
+	}
+	return 1 << key
+}
+
+// Movie ... A recording of every keypad state change in a run, along with everything else needed to reproduce the run exactly.
+// Movies are stored as TOML so they can be read and edited by hand
+// Seed is stored as a hex string because TOML integers cant hold every uint64
+// CyclesPerFrame is the chip's VIP timing budget, or 0 if it ran InstructionsPerFrame instructions every frame
+// Frames and FrameHash are the number of frames the recording ran for and a hash of the display on the last one. filled in by Finish
+type Movie struct {
+	Version              int
+	ROMHash              string
+	Quirks               config.Quirks
+	Random               string
+	Seed                 string
+	InstructionsPerFrame int
+	CyclesPerFrame       int
+	Frames               uint64
+	FrameHash            string
+	Events               []Event
+}
+
+// New ... Creates an empty movie for recording a run of rom
+func New(rom []byte, quirks config.Quirks, random string, seed uint64, instructionsPerFrame, cyclesPerFrame int) *Movie {
+	return &Movie{
+		Version:              Version,
+		ROMHash:              HashROM(rom),
+		Quirks:               quirks,
+		Random:               random,
+		Seed:                 fmt.Sprintf("%#x", seed),
+		InstructionsPerFrame: instructionsPerFrame,
+		CyclesPerFrame:       cyclesPerFrame,
+	}
+}
+
+// Load ... Reads a movie saved by Save
+func Load(path string) (*Movie, error) {
+	var m Movie
+	if _, err := toml.DecodeFile(path, &m); err != nil {
+		return nil, fmt.Errorf("error in movie/Load(): %w", err)
+	}
+	if m.Version != Version {
+		return nil, fmt.Errorf("error in movie/Load(): unsupported movie version %d", m.Version)
+	}
+	if _, err := m.RandomSeed(); err != nil {
+		return nil, fmt.Errorf("error in movie/Load(): %w", err)
+	}
+	return &m, nil
+}
+
+// Save ... Writes the movie to path, replacing whatever was stored there
+func (m *Movie) Save(path string) error {
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(m); err != nil {
+		return fmt.Errorf("error in movie/Movie.Save(): %w", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("error in movie/Movie.Save(): %w", err)
+	}
+	return nil
+}
+
+// RandomSeed ... Returns the seed the chip's random source was created with
+func (m *Movie) RandomSeed() (uint64, error) {
+	seed, err := strconv.ParseUint(m.Seed, 0, 64)
+	if err != nil {
+		return 0, fmt.Errorf("error in movie/Movie.RandomSeed(): invalid seed %q", m.Seed)
+	}
+	return seed, nil
+}
+
+// CheckROM ... Returns ErrROMMismatch if rom isnt the ROM the movie was recorded with
+func (m *Movie) CheckROM(rom []byte) error {
+	if HashROM(rom) != m.ROMHash {
+		return fmt.Errorf("error in movie/Movie.CheckROM(): %w", ErrROMMismatch)
+	}
+	return nil
+}
+
+// Finish ... Marks the end of a recording at the chip's current frame, and stores a hash of its display to verify playback against
+func (m *Movie) Finish(chip *chip8.Chip8) {
+	m.Frames = chip.Frame
+	m.FrameHash = HashDisplay(chip)
+}
+
+// Verify ... Runs a chip created with this movie's Player as fast as possible until the frame the recording ended on,
+// and returns ErrDesync if its display doesnt match the recording's. An error running the chip is returned as it is, since the recording never hit it
+func (m *Movie) Verify(chip *chip8.Chip8) error {
+	for chip.Frame < m.Frames && !chip.Exited() {
+		if err := chip.RunFrame(m.InstructionsPerFrame); err != nil {
+			return fmt.Errorf("error in movie/Movie.Verify(): playback halted on frame %d: %w", chip.Frame, err)
+		}
+	}
+	if chip.Frame != m.Frames || HashDisplay(chip) != m.FrameHash {
+		return fmt.Errorf("error in movie/Movie.Verify(): %w: ended on frame %d with display %s, expected frame %d with display %s",
+			ErrDesync, chip.Frame, HashDisplay(chip), m.Frames, m.FrameHash)
+	}
+	return nil
+}
+
+// HashROM ... Returns the hex encoded SHA-256 hash of a ROM
+func HashROM(rom []byte) string {
+	sum := sha256.Sum256(rom)
+	return hex.EncodeToString(sum[:])
+}
+
+// HashDisplay ... Returns the hex encoded SHA-256 hash of every bitplane of the chip's display
+func HashDisplay(chip *chip8.Chip8) string {
+	sum := sha256.Sum256(chip.Display())
+	return hex.EncodeToString(sum[:])
+}
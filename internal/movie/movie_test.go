@@ -0,0 +1,94 @@
+package movie
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/TH3-F001/GoChip-8/chip8/internal/chip8"
+	"github.com/TH3-F001/GoChip-8/chip8/internal/config"
+)
+
+// drawProgram ... Draws its own first bytes one pixel further right every frame, so that every frame leaves a different display
+var drawProgram = []byte{0xA2, 0x00, 0x70, 0x01, 0xD0, 0x15, 0x12, 0x02}
+
+// newChip ... Creates a chip with no display running rom, its keypad played back from m
+func newChip(t *testing.T, m *Movie, rom []byte) *chip8.Chip8 {
+	t.Helper()
+	chip, err := chip8.New(m.Quirks, nil, NewPlayer(m, &fakeKeypad{last: NoKey}), nil, chip8.NewPCGRandom(1), rom, nil, nil, 32, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return chip
+}
+
+// record ... Returns a movie of frames frames of drawProgram
+func record(t *testing.T, frames int) *Movie {
+	t.Helper()
+	m := New(drawProgram, config.Quirks{DisplayWait: true}, "pcg", 1, 10, 0)
+	chip := newChip(t, m, drawProgram)
+	for i := 0; i < frames; i++ {
+		if err := chip.RunFrame(m.InstructionsPerFrame); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m.Finish(chip)
+	return m
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	m := record(t, 5)
+	m.Events = []Event{{Frame: 3, Key: 0xA, Keys: keyBit(0xA) | keyBit(0x2)}, {Frame: 9, Key: NoKey}}
+	path := filepath.Join(t.TempDir(), "run.toml")
+	if err := m.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.ROMHash != m.ROMHash || loaded.Quirks != m.Quirks || loaded.Frames != 5 || loaded.FrameHash != m.FrameHash {
+		t.Errorf("Load() = %+v, want %+v", loaded, m)
+	}
+	if len(loaded.Events) != 2 || loaded.Events[0] != m.Events[0] || loaded.Events[1] != m.Events[1] {
+		t.Errorf("loaded Events = %+v, want %+v", loaded.Events, m.Events)
+	}
+	if seed, err := loaded.RandomSeed(); seed != 1 || err != nil {
+		t.Errorf("RandomSeed() = %d, %v, want 1", seed, err)
+	}
+}
+
+func TestLoadRejectsOtherVersions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.toml")
+	if err := os.WriteFile(path, []byte("Version = 2\nSeed = \"0x1\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("Load() of a version 2 movie error = nil, want an error")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	t.Run("matching playback", func(t *testing.T) {
+		m := record(t, 5)
+		if err := m.Verify(newChip(t, m, drawProgram)); err != nil {
+			t.Errorf("Verify() error = %v, want nil", err)
+		}
+	})
+	t.Run("playback ending on another display", func(t *testing.T) {
+		m := record(t, 5)
+		m.Frames = 4
+		if err := m.Verify(newChip(t, m, drawProgram)); !errors.Is(err, ErrDesync) {
+			t.Errorf("Verify() error = %v, want ErrDesync", err)
+		}
+	})
+	t.Run("playback halted by the chip", func(t *testing.T) {
+		m := record(t, 5)
+		// 00EE with nothing on the stack
+		err := m.Verify(newChip(t, m, []byte{0x00, 0xEE}))
+		if !errors.Is(err, chip8.ErrStackUnderflow) || errors.Is(err, ErrDesync) {
+			t.Errorf("Verify() error = %v, want ErrStackUnderflow", err)
+		}
+	})
+}
@@ -0,0 +1,13 @@
+package palette
+
+// Default ... The colours used for the XO-CHIP's second bitplane and for both planes overlapping when no palette is configured
+var Default [4]uint32 = [4]uint32{0x000000, 0xFFFFFF, 0xAAAAAA, 0x555555}
+
+// Resolve ... Returns the hex RGB colour of each combination of lit bitplanes (none, first, second, both) for the configured colours.
+// fg and bg expect colors as hexcodes. custom optionally overrides each entry, and missing entries fall back on bg, fg, and the Default
+func Resolve(fgColor, bgColor uint32, custom []uint32) [4]uint32 {
+	colors := Default
+	colors[0], colors[1] = bgColor, fgColor
+	copy(colors[:], custom)
+	return colors
+}
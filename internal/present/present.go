@@ -0,0 +1,167 @@
+package present
+
+import (
+	"sync"
+	"time"
+
+	"github.com/TH3-F001/GoChip-8/chip8/internal/chip8"
+	"github.com/TH3-F001/GoChip-8/chip8/internal/framebuffer"
+	"github.com/TH3-F001/GoChip-8/chip8/internal/io"
+)
+
+// FrameRate ... The most frames presented every second
+const FrameRate = 60
+
+// statsWindow ... How long FPS and IPS are averaged over
+const statsWindow = time.Second
+
+// Stats ... How fast the emulator is running. FPS is the number of frames presented every second, and IPS the number of instructions executed every second
+type Stats struct {
+	FPS float64
+	IPS float64
+}
+
+// Presenter ... Wraps an io.Display so that frames are rendered on a goroutine of their own, at a steady rate, instead of by the emulation goroutine.
+// Should be instantiated using present.New() and given to the chip in place of the display
+// Every frame the chip presents is copied and published on frames. Only the newest frame is kept, so frames the render goroutine is too slow for are skipped.
+// The render goroutine presents at most one frame every frameSkip+1 60Hz ticks. If the display is an io.AnimatedIO, the last frame is presented again
+// on every tick without a new frame for as long as the display is animating
+// chip is read by BeginFrame to count instructions, which always runs on the emulation goroutine
+// mu guards stats
+type Presenter struct {
+	io.Display
+	frames    chan *framebuffer.Framebuffer
+	frameSkip int
+	showStats bool
+	chip      *chip8.Chip8
+	done      chan struct{}
+	stopped   chan struct{}
+
+	mu    sync.Mutex
+	stats Stats
+
+	// ipsStart and ipsInstructions ... the start of the current IPS window and the chip's instruction count at that time. only touched by the emulation goroutine
+	ipsStart        time.Time
+	ipsInstructions uint64
+}
+
+// New ... Creates a Presenter that renders to display, skipping frameSkip frames after every frame it presents.
+// If showStats is true and display is an io.StatsIO, it is given the FPS and IPS with every frame
+func New(display io.Display, frameSkip int, showStats bool) *Presenter {
+	return &Presenter{
+		Display:   display,
+		frames:    make(chan *framebuffer.Framebuffer, 1),
+		frameSkip: max(frameSkip, 0),
+		showStats: showStats,
+		done:      make(chan struct{}),
+		stopped:   make(chan struct{}),
+	}
+}
+
+// Attach ... Counts the instructions executed by chip towards the IPS. Must be called before the chip's first frame
+func (p *Presenter) Attach(chip *chip8.Chip8) {
+	p.chip = chip
+}
+
+// Present ... Publishes a copy of a completed frame to the render goroutine, replacing any frame it hasnt rendered yet. never blocks
+func (p *Presenter) Present(fb *framebuffer.Framebuffer) error {
+	frame := fb.Clone()
+	select {
+	case p.frames <- frame:
+		return nil
+	default:
+	}
+	// the render goroutine is behind: drop the stale frame. Present is only ever called from the emulation goroutine, so the send cant block
+	select {
+	case <-p.frames:
+	default:
+	}
+	p.frames <- frame
+	return nil
+}
+
+// BeginFrame ... Updates the IPS, then passes the call on to the display if it is an io.FrameIO
+func (p *Presenter) BeginFrame(frame uint64) {
+	if p.chip != nil {
+		now := time.Now()
+		if p.ipsStart.IsZero() {
+			p.ipsStart, p.ipsInstructions = now, p.chip.Instructions
+		} else if elapsed := now.Sub(p.ipsStart); elapsed >= statsWindow {
+			ips := float64(p.chip.Instructions-p.ipsInstructions) / elapsed.Seconds()
+			p.ipsStart, p.ipsInstructions = now, p.chip.Instructions
+			p.mu.Lock()
+			p.stats.IPS = ips
+			p.mu.Unlock()
+		}
+	}
+	if framer, ok := p.Display.(io.FrameIO); ok {
+		framer.BeginFrame(frame)
+	}
+}
+
+// Stats ... Returns the most recently measured FPS and IPS
+func (p *Presenter) Stats() Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.stats
+}
+
+// Start ... Starts the render goroutine
+func (p *Presenter) Start() {
+	go p.render()
+}
+
+// Stop ... Stops the render goroutine and waits for it to finish rendering. The Presenter cant be restarted
+func (p *Presenter) Stop() {
+	close(p.done)
+	<-p.stopped
+}
+
+// render ... Presents the newest frame to the display once every frameSkip+1 ticks until Stop is called.
+// Errors from the display are dropped, since there is no one on this goroutine to return them to
+func (p *Presenter) render() {
+	defer close(p.stopped)
+	ticker := time.NewTicker(time.Duration(p.frameSkip+1) * time.Second / FrameRate)
+	defer ticker.Stop()
+
+	statsIO, _ := p.Display.(io.StatsIO)
+	animated, _ := p.Display.(io.AnimatedIO)
+	windowStart, presented := time.Now(), 0
+	var last *framebuffer.Framebuffer
+	for {
+		var frame *framebuffer.Framebuffer
+		select {
+		case frame = <-p.frames:
+		default:
+			if last != nil && animated != nil && animated.Animating() {
+				frame = last
+				break
+			}
+			select {
+			case <-p.done:
+				return
+			case frame = <-p.frames:
+			}
+		}
+
+		if elapsed := time.Since(windowStart); elapsed >= statsWindow {
+			p.mu.Lock()
+			p.stats.FPS = float64(presented) / elapsed.Seconds()
+			p.mu.Unlock()
+			windowStart, presented = time.Now(), 0
+		}
+		if statsIO != nil && p.showStats {
+			stats := p.Stats()
+			statsIO.ShowStats(stats.FPS, stats.IPS)
+		}
+		p.Display.Present(frame)
+		presented++
+		last = frame
+
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
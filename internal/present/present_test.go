@@ -0,0 +1,141 @@
+package present
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/TH3-F001/GoChip-8/chip8/internal/framebuffer"
+)
+
+// fakeDisplay ... An io.AnimatedIO and io.StatsIO that records every frame presented to it. It animates until animateFor frames have been presented
+type fakeDisplay struct {
+	mu         sync.Mutex
+	presented  []*framebuffer.Framebuffer
+	animateFor int
+	statsShown int
+}
+
+func (d *fakeDisplay) Present(fb *framebuffer.Framebuffer) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.presented = append(d.presented, fb.Clone())
+	return nil
+}
+
+func (d *fakeDisplay) Animating() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.presented) < d.animateFor
+}
+
+func (d *fakeDisplay) ShowStats(fps, ips float64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.statsShown++
+}
+
+// count ... Returns the number of frames presented so far
+func (d *fakeDisplay) count() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.presented)
+}
+
+// numbered ... Returns a frame with pixel n of its first row lit, so that frames can be told apart
+func numbered(t *testing.T, n int) *framebuffer.Framebuffer {
+	t.Helper()
+	fb, err := framebuffer.New(32, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fb.Set(0, 0, n, true)
+	return fb
+}
+
+// waitFor ... Fails the test if display hasnt been presented want frames within a second
+func waitFor(t *testing.T, display *fakeDisplay, want int) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); display.count() < want; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d frames presented, want %d", display.count(), want)
+		}
+	}
+}
+
+func TestOnlyNewestFrameIsPresented(t *testing.T) {
+	display := &fakeDisplay{}
+	p := New(display, 0, false)
+	// the render goroutine hasnt started, so every frame but the last is skipped without Present blocking
+	var fb *framebuffer.Framebuffer
+	for n := 1; n <= 3; n++ {
+		fb = numbered(t, n)
+		p.Present(fb)
+	}
+	// the frame is copied, so the chip drawing the next one doesnt change it
+	fb.Set(0, 0, 3, false)
+
+	p.Start()
+	waitFor(t, display, 1)
+	time.Sleep(50 * time.Millisecond)
+	p.Stop()
+	if display.count() != 1 || !display.presented[0].Get(0, 0, 3) {
+		t.Errorf("%d frames presented, want only frame 3", display.count())
+	}
+	if display.statsShown != 0 {
+		t.Error("stats were shown without showStats")
+	}
+}
+
+func TestFrameSkipPacesPresents(t *testing.T) {
+	tests := []struct {
+		name      string
+		frameSkip int
+		// at most one frame every frameSkip+1 ticks of 60Hz over the 500ms, plus the one presented straight away
+		wantMax int
+	}{
+		{name: "every tick", frameSkip: 0, wantMax: 31},
+		{name: "every third tick", frameSkip: 2, wantMax: 11},
+		{name: "negative skips nothing", frameSkip: -4, wantMax: 31},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			display := &fakeDisplay{}
+			p := New(display, tt.frameSkip, true)
+			p.Start()
+			// frames arrive far faster than they can be presented, the way they do when the emulator runs unpaced
+			for n, start := 0, time.Now(); time.Since(start) < 500*time.Millisecond; n++ {
+				p.Present(numbered(t, n%64))
+				time.Sleep(time.Millisecond)
+			}
+			p.Stop()
+			if got := display.count(); got > tt.wantMax || got < tt.wantMax/3 {
+				t.Errorf("%d frames presented in 500ms, want up to %d", got, tt.wantMax)
+			}
+			if display.statsShown != display.count() {
+				t.Errorf("stats shown with %d of %d frames, want all of them", display.statsShown, display.count())
+			}
+		})
+	}
+}
+
+func TestAnimatingDisplayIsPresentedAgain(t *testing.T) {
+	display := &fakeDisplay{animateFor: 4}
+	p := New(display, 0, false)
+	p.Present(numbered(t, 7))
+	p.Start()
+	waitFor(t, display, 4)
+	// once it stops animating, the last frame isnt presented again until there is a new one
+	time.Sleep(100 * time.Millisecond)
+	if display.count() != 4 {
+		t.Errorf("%d frames presented, want 4", display.count())
+	}
+	p.Present(numbered(t, 8))
+	waitFor(t, display, 5)
+	p.Stop()
+	for i, fb := range display.presented {
+		if want := 7 + i/4; !fb.Get(0, 0, want) {
+			t.Errorf("frame %d presented isnt frame %d", i, want)
+		}
+	}
+}
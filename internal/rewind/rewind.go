@@ -0,0 +1,114 @@
+package rewind
+
+import (
+	"encoding/binary"
+)
+
+// Buffer ... A ring buffer of chip8 snapshots used to step back through recent frames. Should be instantiated using rewind.New()
+// Only the most recent snapshot is stored whole (head). every older snapshot is stored as a delta: the XOR of it and the snapshot after it,
+// run-length encoded so that the (mostly unchanged) zero bytes take up almost no space
+// deltas is the ring of encoded deltas, where start is the index of the oldest and count is how many are stored
+type Buffer struct {
+	head   []byte
+	deltas [][]byte
+	start  int
+	count  int
+}
+
+// New ... Creates a Buffer that can step back up to depth snapshots
+func New(depth int) *Buffer {
+	return &Buffer{deltas: make([][]byte, depth)}
+}
+
+// Len ... Returns the number of snapshots that can currently be stepped back through
+func (b *Buffer) Len() int {
+	return b.count
+}
+
+// Push ... Records a snapshot as the most recent state. Once the buffer is full, the oldest snapshot is forgotten.
+// Snapshots of a different length to the previous one (ie. from a different chip) clear the buffer
+func (b *Buffer) Push(snapshot []byte) {
+	if len(b.deltas) == 0 {
+		return
+	}
+	if b.head != nil && len(b.head) == len(snapshot) {
+		delta := encode(xor(b.head, snapshot))
+		if b.count == len(b.deltas) {
+			b.deltas[b.start] = delta
+			b.start = (b.start + 1) % len(b.deltas)
+		} else {
+			b.deltas[(b.start+b.count)%len(b.deltas)] = delta
+			b.count++
+		}
+	} else {
+		b.start, b.count = 0, 0
+	}
+	b.head = append(b.head[:0], snapshot...)
+}
+
+// Pop ... Steps back one snapshot, and returns it. returns false if there is nothing left to step back to
+func (b *Buffer) Pop() ([]byte, bool) {
+	if b.count == 0 {
+		return nil, false
+	}
+	b.count--
+	last := (b.start + b.count) % len(b.deltas)
+	decode(b.deltas[last], b.head)
+	b.deltas[last] = nil
+
+	snapshot := make([]byte, len(b.head))
+	copy(snapshot, b.head)
+	return snapshot, true
+}
+
+// Clear ... Forgets every stored snapshot
+func (b *Buffer) Clear() {
+	b.head = nil
+	b.start, b.count = 0, 0
+	clear(b.deltas)
+}
+
+// xor ... Returns a new slice holding a XOR b. a and b must be the same length
+func xor(a, b []byte) []byte {
+	result := make([]byte, len(a))
+	for i := range a {
+		result[i] = a[i] ^ b[i]
+	}
+	return result
+}
+
+// encode ... Run-length encodes the zero bytes of a delta as a series of (zero run length, literal length, literal bytes) records with varint lengths
+func encode(delta []byte) []byte {
+	encoded := make([]byte, 0, 64)
+	for i := 0; i < len(delta); {
+		zeros := i
+		for i < len(delta) && delta[i] == 0 {
+			i++
+		}
+		literals := i
+		for i < len(delta) && delta[i] != 0 {
+			i++
+		}
+		encoded = binary.AppendUvarint(encoded, uint64(literals-zeros))
+		encoded = binary.AppendUvarint(encoded, uint64(i-literals))
+		encoded = append(encoded, delta[literals:i]...)
+	}
+	return encoded
+}
+
+// decode ... XORs an encoded delta back into dst
+func decode(encoded []byte, dst []byte) {
+	pos := 0
+	for len(encoded) > 0 {
+		zeros, n := binary.Uvarint(encoded)
+		encoded = encoded[n:]
+		literals, n := binary.Uvarint(encoded)
+		encoded = encoded[n:]
+		pos += int(zeros)
+		for i := 0; i < int(literals); i++ {
+			dst[pos+i] ^= encoded[i]
+		}
+		pos += int(literals)
+		encoded = encoded[literals:]
+	}
+}
@@ -0,0 +1,115 @@
+package rewind
+
+import (
+	"bytes"
+	"testing"
+)
+
+// snapshot ... Returns a 300 byte snapshot that differs from the snapshots of other values of n in a few scattered bytes
+func snapshot(n byte) []byte {
+	s := make([]byte, 300)
+	s[0], s[150], s[299] = n, n*2, n*3
+	return s
+}
+
+func TestPushPopWithinDepth(t *testing.T) {
+	b := New(3)
+	if _, ok := b.Pop(); ok {
+		t.Error("Pop() of an empty buffer ok = true, want false")
+	}
+	for n := byte(1); n <= 3; n++ {
+		b.Push(snapshot(n))
+	}
+	// the most recent snapshot is the current state, so only the ones before it can be stepped back to
+	if b.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", b.Len())
+	}
+	for _, want := range []byte{2, 1} {
+		got, ok := b.Pop()
+		if !ok || !bytes.Equal(got, snapshot(want)) {
+			t.Fatalf("Pop() = % x, %v, want snapshot %d", got, ok, want)
+		}
+	}
+	if _, ok := b.Pop(); ok {
+		t.Error("Pop() past the oldest snapshot ok = true, want false")
+	}
+}
+
+func TestPushPastDepthForgetsOldest(t *testing.T) {
+	b := New(3)
+	for n := byte(1); n <= 10; n++ {
+		b.Push(snapshot(n))
+	}
+	if b.Len() != 3 {
+		t.Errorf("Len() = %d, want 3", b.Len())
+	}
+	// stepping back part way and pushing again carries on from the snapshot stepped back to
+	for _, want := range []byte{9, 8} {
+		if got, ok := b.Pop(); !ok || !bytes.Equal(got, snapshot(want)) {
+			t.Fatalf("Pop() = % x, %v, want snapshot %d", got, ok, want)
+		}
+	}
+	b.Push(snapshot(20))
+	b.Push(snapshot(21))
+	for _, want := range []byte{20, 8, 7} {
+		if got, ok := b.Pop(); !ok || !bytes.Equal(got, snapshot(want)) {
+			t.Fatalf("Pop() = % x, %v, want snapshot %d", got, ok, want)
+		}
+	}
+	if _, ok := b.Pop(); ok {
+		t.Error("Pop() past the depth ok = true, want false")
+	}
+}
+
+func TestPushClears(t *testing.T) {
+	b := New(0)
+	b.Push(snapshot(1))
+	b.Push(snapshot(2))
+	if _, ok := b.Pop(); ok || b.Len() != 0 {
+		t.Error("a buffer of depth 0 stored a snapshot")
+	}
+
+	b = New(4)
+	b.Push(snapshot(1))
+	b.Push(snapshot(2))
+	b.Push(make([]byte, 10))
+	if b.Len() != 0 {
+		t.Errorf("Len() after a snapshot of another length = %d, want 0", b.Len())
+	}
+	b.Push(snapshot(3))
+	b.Push(snapshot(4))
+	b.Clear()
+	if _, ok := b.Pop(); ok {
+		t.Error("Pop() after Clear() ok = true, want false")
+	}
+}
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	long := bytes.Repeat([]byte{0xAB}, 200)
+	tests := []struct {
+		name  string
+		delta []byte
+	}{
+		{name: "empty", delta: []byte{}},
+		{name: "all zeros", delta: make([]byte, 1000)},
+		{name: "no zeros", delta: []byte{1, 2, 3, 4}},
+		{name: "leading and trailing zeros", delta: []byte{0, 0, 5, 6, 0, 7, 0, 0, 0}},
+		{name: "runs too long for a one byte varint", delta: append(append(make([]byte, 300), long...), make([]byte, 130)...)},
+		{name: "alternating", delta: []byte{1, 0, 1, 0, 1, 0, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded := encode(tt.delta)
+			// decoding XORs the delta in, so it is checked against bytes that arent all zero
+			dst := bytes.Repeat([]byte{0x0F}, len(tt.delta))
+			decode(encoded, dst)
+			want := xor(bytes.Repeat([]byte{0x0F}, len(tt.delta)), tt.delta)
+			if !bytes.Equal(dst, want) {
+				t.Errorf("decode(encode()) = % x, want % x", dst, want)
+			}
+		})
+	}
+	if encoded := encode(make([]byte, 1000)); len(encoded) > 3 {
+		t.Errorf("encode() of 1000 zero bytes is %d bytes long, want at most 3", len(encoded))
+	}
+}
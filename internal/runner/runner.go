@@ -0,0 +1,152 @@
+package runner
+
+import (
+	"time"
+
+	"github.com/TH3-F001/GoChip-8/chip8/internal/chip8"
+	"github.com/TH3-F001/GoChip-8/chip8/internal/io"
+	"github.com/TH3-F001/GoChip-8/chip8/internal/rewind"
+)
+
+// FrameRate ... The number of frames the chip8 emulates per second of real time
+const FrameRate = 60
+
+// rewindHoldFrames ... How many frames the runner keeps rewinding for after an io.HotkeyRewind, io.KeyHold as frames, since held keys are only seen as they repeat
+const rewindHoldFrames = int(io.KeyHold * FrameRate / time.Second)
+
+// Runner ... Paces a Chip8 in real time by running one emulated frame per 60Hz tick of the wall clock. Should be instantiated using runner.New()
+// chip is the chip8 instance being run
+// instructionsPerFrame is the number of instructions handed to chip8.RunFrame() every frame
+// hotkeyCh and hotkeyFunc are set by HandleHotkeys. hotkeys are handled between frames so they never interrupt an instruction
+// rewind holds a snapshot of every recent frame when rewinding is enabled, and rewindFrames counts down the frames left to play backwards
+// stopFrame is the frame Run stops at. 0 runs until the program exits
+// frameFunc is set by AfterFrame, and called after every frame run or rewound
+// unpaced is true when frames are run back to back instead of in real time
+type Runner struct {
+	chip                 *chip8.Chip8
+	instructionsPerFrame int
+	hotkeyCh             <-chan io.Hotkey
+	hotkeyFunc           func(io.Hotkey)
+	rewind               *rewind.Buffer
+	rewindFrames         int
+	stopFrame            uint64
+	frameFunc            func()
+	unpaced              bool
+}
+
+// immediately ... A closed channel, received from in place of the ticker when the runner is unpaced
+var immediately = func() chan time.Time {
+	ch := make(chan time.Time)
+	close(ch)
+	return ch
+}()
+
+// New ... Creates a Runner for the given chip, that executes instructionsPerSecond instructions every second spread evenly over each frame
+func New(chip *chip8.Chip8, instructionsPerSecond uint32) *Runner {
+	ipf := int(instructionsPerSecond) / FrameRate
+	if ipf < 1 {
+		ipf = 1
+	}
+	return &Runner{
+		chip:                 chip,
+		instructionsPerFrame: ipf,
+	}
+}
+
+// InstructionsPerFrame ... Returns the number of instructions the runner executes every frame
+func (r *Runner) InstructionsPerFrame() int {
+	return r.instructionsPerFrame
+}
+
+// HandleHotkeys ... Makes Run call handle on its own goroutine, between frames, with each hotkey received from hotkeyCh.
+// io.HotkeyRewind is handled by the runner itself when rewinding is enabled
+func (r *Runner) HandleHotkeys(hotkeyCh <-chan io.Hotkey, handle func(io.Hotkey)) {
+	r.hotkeyCh = hotkeyCh
+	r.hotkeyFunc = handle
+}
+
+// EnableRewind ... Makes Run capture the chip's state every frame, so that holding the rewind hotkey can play back up to depth frames in reverse
+func (r *Runner) EnableRewind(depth int) {
+	if depth > 0 {
+		r.rewind = rewind.New(depth)
+	}
+}
+
+// AfterFrame ... Makes Run call fn on its own goroutine after every frame it runs or rewinds
+func (r *Runner) AfterFrame(fn func()) {
+	r.frameFunc = fn
+}
+
+// DisablePacing ... Makes Run run frames back to back as fast as it can, rather than one every 60th of a second. For runs without a display
+func (r *Runner) DisablePacing() {
+	r.unpaced = true
+}
+
+// StopAtFrame ... Makes Run return once the chip reaches the given frame
+func (r *Runner) StopAtFrame(frame uint64) {
+	r.stopFrame = frame
+}
+
+// Run ... Runs a frame every 60th of a second until termCh receives a value, the chip's program exits, the chip reaches the frame set by StopAtFrame,
+// or the chip halts on an error. Blocks until then.
+// returns the error the chip halted on, if any
+func (r *Runner) Run(termCh <-chan bool) error {
+	ticker := time.NewTicker(time.Second / FrameRate)
+	defer ticker.Stop()
+	tick := ticker.C
+	if r.unpaced {
+		tick = immediately
+	}
+
+	if err := r.capture(); err != nil {
+		return err
+	}
+	for !r.chip.Exited() && (r.stopFrame == 0 || r.chip.Frame < r.stopFrame) {
+		select {
+		case <-termCh:
+			return nil
+		case hotkey := <-r.hotkeyCh:
+			if hotkey == io.HotkeyRewind && r.rewind != nil {
+				r.rewindFrames = rewindHoldFrames
+			} else if r.hotkeyFunc != nil {
+				r.hotkeyFunc(hotkey)
+			}
+		case <-tick:
+			if err := r.frame(); err != nil {
+				return err
+			}
+			if r.frameFunc != nil {
+				r.frameFunc()
+			}
+		}
+	}
+	return nil
+}
+
+// frame ... Runs a single frame, or while rewinding, restores the frame before the current one
+func (r *Runner) frame() error {
+	if r.rewindFrames > 0 {
+		r.rewindFrames--
+		if snapshot, ok := r.rewind.Pop(); ok {
+			return r.chip.Restore(snapshot)
+		}
+		return nil
+	}
+	if err := r.chip.RunFrame(r.instructionsPerFrame); err != nil {
+		return err
+	}
+	return r.capture()
+}
+
+// capture ... Records the chip's current state in the rewind buffer if rewinding is enabled
+func (r *Runner) capture() error {
+	if r.rewind == nil {
+		return nil
+	}
+	snapshot, err := r.chip.Snapshot()
+	if err != nil {
+		return err
+	}
+	r.rewind.Push(snapshot)
+	return nil
+}
@@ -0,0 +1,74 @@
+package savestate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/TH3-F001/GoChip-8/chip8/internal/chip8"
+)
+
+// NumSlots ... The number of save slots available for each ROM
+const NumSlots = 10
+
+// Slots ... Stores chip8 snapshots in numbered slot files for a single ROM. Should be instantiated using savestate.New()
+// dir is the directory that slot files are stored in
+// rom is the name of the ROM the slots belong to, used to prefix each slot's file name
+// Current is the slot that Save and Load act on
+type Slots struct {
+	dir     string
+	rom     string
+	Current int
+}
+
+// New ... Creates the save directory if it doesnt exist yet, and returns a Slots instance for the given ROM
+// romPath may be a file path, only its base name (without extension) is used
+func New(dir, romPath string) (*Slots, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("error in savestate/New(): %w", err)
+	}
+	rom := strings.TrimSuffix(filepath.Base(romPath), filepath.Ext(romPath))
+	return &Slots{dir: dir, rom: rom}, nil
+}
+
+// Path ... Returns the path of the file that the given slot is stored in
+func (s *Slots) Path(slot int) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%s.%d.state", s.rom, slot))
+}
+
+// Next ... Selects the next slot, wrapping around after the last one. returns the selected slot
+func (s *Slots) Next() int {
+	s.Current = (s.Current + 1) % NumSlots
+	return s.Current
+}
+
+// Prev ... Selects the previous slot, wrapping around before the first one. returns the selected slot
+func (s *Slots) Prev() int {
+	s.Current = (s.Current + NumSlots - 1) % NumSlots
+	return s.Current
+}
+
+// Save ... Writes a snapshot of the chip to the current slot, replacing whatever was stored there
+func (s *Slots) Save(chip *chip8.Chip8) error {
+	data, err := chip.Snapshot()
+	if err != nil {
+		return fmt.Errorf("error in savestate/Slots.Save(): %w", err)
+	}
+	if err := os.WriteFile(s.Path(s.Current), data, 0644); err != nil {
+		return fmt.Errorf("error in savestate/Slots.Save(): %w", err)
+	}
+	return nil
+}
+
+// Load ... Restores the chip from the snapshot stored in the current slot
+func (s *Slots) Load(chip *chip8.Chip8) error {
+	data, err := os.ReadFile(s.Path(s.Current))
+	if err != nil {
+		return fmt.Errorf("error in savestate/Slots.Load(): %w", err)
+	}
+	if err := chip.Restore(data); err != nil {
+		return fmt.Errorf("error in savestate/Slots.Load(): %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package savestate
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/TH3-F001/GoChip-8/chip8/internal/chip8"
+	"github.com/TH3-F001/GoChip-8/chip8/internal/config"
+)
+
+// newChip ... Creates a chip with no IO running a program that counts up in V0 every instruction
+func newChip(t *testing.T) *chip8.Chip8 {
+	t.Helper()
+	chip, err := chip8.New(config.Quirks{}, nil, nil, nil, chip8.NewPCGRandom(1), []byte{0x70, 0x01, 0x12, 0x00}, nil, nil, 32, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return chip
+}
+
+func TestNewNamesSlotsAfterTheROM(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "states")
+	slots, err := New(dir, "/roms/Space Invaders.ch8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		t.Fatalf("New() didnt create the save directory: %v", err)
+	}
+	if got, want := slots.Path(3), filepath.Join(dir, "Space Invaders.3.state"); got != want {
+		t.Errorf("Path(3) = %q, want %q", got, want)
+	}
+}
+
+func TestSlotSelectionWraps(t *testing.T) {
+	slots, err := New(t.TempDir(), "rom.ch8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := slots.Prev(); got != NumSlots-1 {
+		t.Errorf("Prev() from slot 0 = %d, want %d", got, NumSlots-1)
+	}
+	if got := slots.Next(); got != 0 {
+		t.Errorf("Next() from the last slot = %d, want 0", got)
+	}
+	if got := slots.Next(); got != 1 || slots.Current != 1 {
+		t.Errorf("Next() from slot 0 = %d with Current %d, want 1", got, slots.Current)
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	slots, err := New(t.TempDir(), "rom.ch8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	chip := newChip(t)
+	if err := chip.RunFrame(10); err != nil {
+		t.Fatal(err)
+	}
+	slots.Next()
+	if err := slots.Save(chip); err != nil {
+		t.Fatal(err)
+	}
+	saved := chip.V[0]
+	if err := chip.RunFrame(10); err != nil {
+		t.Fatal(err)
+	}
+
+	// each slot keeps its own snapshot, and an empty one cant be loaded
+	slots.Prev()
+	if err := slots.Load(chip); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Load() of an empty slot error = %v, want os.ErrNotExist", err)
+	}
+	slots.Next()
+	if err := slots.Load(chip); err != nil {
+		t.Fatal(err)
+	}
+	if chip.V[0] != saved {
+		t.Errorf("V0 after Load() = %d, want %d", chip.V[0], saved)
+	}
+
+	if err := os.WriteFile(slots.Path(slots.Current), []byte("not a snapshot"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := slots.Load(chip); !errors.Is(err, chip8.ErrInvalidSnapshot) {
+		t.Errorf("Load() of a damaged slot error = %v, want chip8.ErrInvalidSnapshot", err)
+	}
+}
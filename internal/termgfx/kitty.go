@@ -0,0 +1,48 @@
+package termgfx
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+)
+
+// kittyChunk ... The most base64 bytes the kitty graphics protocol takes in a single escape sequence
+const kittyChunk = 4096
+
+// KittyClear ... The escape sequence that deletes every image drawn with the kitty graphics protocol
+const KittyClear = "\033_Ga=d,d=A,q=2\033\\"
+
+// EncodeKitty ... Writes img to w as a PNG image for the kitty graphics protocol, drawn at the cursor without moving it.
+// Images with the same id replace each other in place, so a frame drawn with the id of the last one replaces it rather than piling up.
+// The terminal is asked not to reply, since replies would arrive on standard input along with the keyboard. The output only depends on img and id
+func EncodeKitty(w io.Writer, img image.Image, id int) error {
+	// the compression level is fixed so the output only depends on the image. PNG compresses flat pixel art far better than raw pixels
+	var data bytes.Buffer
+	encoder := png.Encoder{CompressionLevel: png.BestSpeed}
+	if err := encoder.Encode(&data, img); err != nil {
+		return fmt.Errorf("error in termgfx/EncodeKitty(): %w", err)
+	}
+	payload := base64.StdEncoding.EncodeToString(data.Bytes())
+
+	out := bufio.NewWriter(w)
+	for start := 0; start < len(payload); start += kittyChunk {
+		end := min(start+kittyChunk, len(payload))
+		more := 0
+		if end < len(payload) {
+			more = 1
+		}
+		// only the first chunk carries the image's keys. the rest only say whether more follow, and that no reply is wanted
+		if start == 0 {
+			fmt.Fprintf(out, "\033_Ga=T,f=100,i=%d,p=1,C=1,q=2,m=%d;", id, more)
+		} else {
+			fmt.Fprintf(out, "\033_Gm=%d,q=2;", more)
+		}
+		out.WriteString(payload[start:end])
+		out.WriteString("\033\\")
+	}
+	return out.Flush()
+}
@@ -0,0 +1,94 @@
+package termgfx
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"io"
+)
+
+// sixelRows ... The number of pixel rows drawn by every sixel character
+const sixelRows = 6
+
+// minRepeat ... The shortest run of a sixel character written with a repeat introducer. Shorter runs are cheaper written out
+const minRepeat = 4
+
+// EncodeSixel ... Writes img to w as a sixel image, drawn from the cursor down. Every colour of img's palette is defined as a colour register,
+// and then every band of 6 rows is drawn one colour at a time, with runs of the same character compressed. The output only depends on img
+func EncodeSixel(w io.Writer, img *image.Paletted) error {
+	out := bufio.NewWriter(w)
+	bounds := img.Bounds()
+	// P2 = 1 leaves pixels that arent drawn as they were. every pixel is drawn anyway, and the raster attributes set square pixels and the image size
+	fmt.Fprintf(out, "\033P0;1;0q\"1;1;%d;%d", bounds.Dx(), bounds.Dy())
+	for i, c := range img.Palette {
+		r, g, b, _ := c.RGBA()
+		fmt.Fprintf(out, "#%d;2;%d;%d;%d", i, percent(r), percent(g), percent(b))
+	}
+
+	bits := make([]byte, bounds.Dx())
+	for top := bounds.Min.Y; top < bounds.Max.Y; top += sixelRows {
+		if top > bounds.Min.Y {
+			out.WriteByte('-')
+		}
+		first := true
+		for index := range img.Palette {
+			if !sixelBand(img, top, byte(index), bits) {
+				continue
+			}
+			if !first {
+				out.WriteByte('$')
+			}
+			first = false
+			fmt.Fprintf(out, "#%d", index)
+			writeSixels(out, bits)
+		}
+	}
+	out.WriteString("\033\\")
+	return out.Flush()
+}
+
+// percent ... Converts a 16 bit colour channel to the 0 to 100 range of sixel colour registers
+func percent(channel uint32) int {
+	return int((channel*100 + 0xFFFF/2) / 0xFFFF)
+}
+
+// sixelBand ... Fills bits with the sixel bits of the colour at index in the band of 6 rows starting at top. The lowest bit is the top row.
+// returns false if the colour isnt in the band
+func sixelBand(img *image.Paletted, top int, index byte, bits []byte) bool {
+	bounds := img.Bounds()
+	clear(bits)
+	found := false
+	for row := 0; row < sixelRows && top+row < bounds.Max.Y; row++ {
+		offset := img.PixOffset(bounds.Min.X, top+row)
+		for x, pixel := range img.Pix[offset : offset+len(bits)] {
+			if pixel == index {
+				bits[x] |= 1 << row
+				found = true
+			}
+		}
+	}
+	return found
+}
+
+// writeSixels ... Writes a band's bits as sixel characters. Runs of the same character are written with a repeat introducer, and trailing blanks are left out
+func writeSixels(out *bufio.Writer, bits []byte) {
+	end := len(bits)
+	for end > 0 && bits[end-1] == 0 {
+		end--
+	}
+	for x := 0; x < end; {
+		run := 1
+		for x+run < end && bits[x+run] == bits[x] {
+			run++
+		}
+		char := '?' + bits[x]
+		if run >= minRepeat {
+			fmt.Fprintf(out, "!%d%c", run, char)
+		} else {
+			for i := 0; i < run; i++ {
+				out.WriteByte(char)
+			}
+		}
+		x += run
+	}
+}
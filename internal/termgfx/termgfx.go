@@ -0,0 +1,98 @@
+package termgfx
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Protocol ... A way of drawing bitmaps in a terminal
+type Protocol int
+
+const (
+	// None ... The terminal can only draw characters
+	None Protocol = iota
+	// Sixel ... DEC's sixel graphics, supported by xterm, foot, mlterm, WezTerm and others
+	Sixel
+	// Kitty ... The kitty graphics protocol, supported by kitty, WezTerm, Ghostty and Konsole
+	Kitty
+	// Detect ... Not a protocol, but a request to use the best one the terminal answers Query for
+	Detect
+)
+
+// #region Detection
+
+// kittyQueryID ... The image id key of the kitty query. Only used to recognise its reply
+const kittyQueryID = "i=31"
+
+// Query ... The escape sequences that ask the terminal for its cell size in pixels, whether it supports the kitty graphics protocol,
+// and its primary device attributes, which list sixel support. Every terminal answers the device attributes, and answers them last,
+// so their reply marks the end of the replies
+const Query = "\033_G" + kittyQueryID + ",s=1,v=1,a=q,t=d,f=24;AAAA\033\\" + "\033[16t" + "\033[c"
+
+// defaultCell ... The width and height in pixels assumed for a cell when the terminal doesnt report them
+var defaultCell = [2]int{10, 20}
+
+// Detection ... What the terminal said in reply to Query. Should be instantiated using termgfx.NewDetection()
+// Kitty is true if the terminal accepted the kitty query, and Sixel if its device attributes list sixel graphics.
+// Cell is the width and height of a cell in pixels, or defaultCell if the terminal didnt say. Done is true once the device attributes have arrived
+type Detection struct {
+	Kitty bool
+	Sixel bool
+	Cell  [2]int
+	Done  bool
+}
+
+// NewDetection ... Creates a Detection that hasnt had any replies yet
+func NewDetection() *Detection {
+	return &Detection{Cell: defaultCell}
+}
+
+// Reply ... Takes a single escape sequence the terminal sent, and returns true if it was a reply to Query
+func (d *Detection) Reply(seq string) bool {
+	switch {
+	case strings.HasPrefix(seq, "\033_G"):
+		// ESC _ G i=31;OK ESC \ when the query was accepted, or an error message in place of OK
+		body := strings.TrimSuffix(strings.TrimPrefix(seq, "\033_G"), "\033\\")
+		keys, message, _ := strings.Cut(body, ";")
+		if !strings.Contains(","+keys+",", ","+kittyQueryID+",") {
+			return false
+		}
+		d.Kitty = message == "OK"
+		return true
+	case strings.HasPrefix(seq, "\033[6;") && strings.HasSuffix(seq, "t"):
+		// ESC [ 6 ; height ; width t
+		params := strings.Split(seq[len("\033[6;"):len(seq)-1], ";")
+		if len(params) != 2 {
+			return false
+		}
+		height, errH := strconv.Atoi(params[0])
+		width, errW := strconv.Atoi(params[1])
+		if errH == nil && errW == nil && width > 0 && height > 0 {
+			d.Cell = [2]int{width, height}
+		}
+		return true
+	case strings.HasPrefix(seq, "\033[?") && strings.HasSuffix(seq, "c"):
+		// ESC [ ? class ; feature ; ... c, where feature 4 is sixel graphics
+		for _, param := range strings.Split(seq[len("\033[?"):len(seq)-1], ";") {
+			if param == "4" {
+				d.Sixel = true
+			}
+		}
+		d.Done = true
+		return true
+	}
+	return false
+}
+
+// Best ... Returns the best protocol the terminal supports. Kitty is preferred, since it is sent compressed and replaces the last frame in place
+func (d *Detection) Best() Protocol {
+	switch {
+	case d.Kitty:
+		return Kitty
+	case d.Sixel:
+		return Sixel
+	}
+	return None
+}
+
+//#endregion
@@ -0,0 +1,181 @@
+package termgfx
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+// paletted ... Returns a width by height image of palette, with pixels given row by row as palette indexes
+func paletted(width, height int, palette color.Palette, pixels ...byte) *image.Paletted {
+	img := image.NewPaletted(image.Rect(0, 0, width, height), palette)
+	copy(img.Pix, pixels)
+	return img
+}
+
+var blackWhite = color.Palette{color.Black, color.White}
+
+func TestEncodeSixel(t *testing.T) {
+	tests := []struct {
+		name string
+		img  *image.Paletted
+		want string
+	}{
+		{
+			name: "each colour is drawn in turn, with trailing blanks left out",
+			img: paletted(5, 2, blackWhite,
+				1, 1, 1, 1, 0,
+				0, 0, 1, 0, 0),
+			want: "\033P0;1;0q\"1;1;5;2#0;2;0;0;0#1;2;100;100;100#0AA?AB$#1@@B@\033\\",
+		},
+		{
+			name: "runs shorter than minRepeat are written out",
+			img:  paletted(3, 1, blackWhite, 1, 1, 1),
+			want: "\033P0;1;0q\"1;1;3;1#0;2;0;0;0#1;2;100;100;100#1@@@\033\\",
+		},
+		{
+			name: "longer runs are repeated, and bands are separated by newlines",
+			img: paletted(8, 7, color.Palette{color.Black, color.White, color.RGBA{0x80, 0x40, 0x00, 0xFF}},
+				bytes.Repeat([]byte{1}, 8*7)...),
+			want: "\033P0;1;0q\"1;1;8;7#0;2;0;0;0#1;2;100;100;100#2;2;50;25;0#1!8~-#1!8@\033\\",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := EncodeSixel(&out, tt.img); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("EncodeSixel() = %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeKitty(t *testing.T) {
+	var out bytes.Buffer
+	if err := EncodeKitty(&out, paletted(2, 1, blackWhite, 0, 1), 7); err != nil {
+		t.Fatal(err)
+	}
+	want := "\033_Ga=T,f=100,i=7,p=1,C=1,q=2,m=0;" +
+		"iVBORw0KGgoAAAANSUhEUgAAAAIAAAABAQMAAADO7O3JAAAABlBMVEUAAAD///+l2Z/dAAAAD0lEQVR4AQACAP3/AEADAABCAEGaqHh3AAAAAElFTkSuQmCC\033\\"
+	if out.String() != want {
+		t.Errorf("EncodeKitty() = %q, want %q", out.String(), want)
+	}
+}
+
+func TestEncodeKittyChunks(t *testing.T) {
+	// noise doesnt compress, so the PNG of a big enough image needs several chunks
+	img := image.NewPaletted(image.Rect(0, 0, 128, 128), color.Palette{color.Black, color.White, color.Gray{0x55}, color.Gray{0xAA}})
+	seed := uint32(1)
+	for i := range img.Pix {
+		seed = seed*1664525 + 1013904223
+		img.Pix[i] = byte(seed >> 30)
+	}
+	var out bytes.Buffer
+	if err := EncodeKitty(&out, img, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	sequences := strings.SplitAfter(out.String(), "\033\\")
+	sequences = sequences[:len(sequences)-1]
+	if len(sequences) < 2 {
+		t.Fatalf("EncodeKitty() wrote %d escape sequences, want the image split into several", len(sequences))
+	}
+	var payload strings.Builder
+	for i, seq := range sequences {
+		header := "\033_Gm=1,q=2;"
+		switch {
+		case i == 0:
+			header = "\033_Ga=T,f=100,i=3,p=1,C=1,q=2,m=1;"
+		case i == len(sequences)-1:
+			header = "\033_Gm=0,q=2;"
+		}
+		if !strings.HasPrefix(seq, header) {
+			t.Fatalf("sequence %d = %.40q..., want it to start %q", i, seq, header)
+		}
+		chunk := strings.TrimSuffix(strings.TrimPrefix(seq, header), "\033\\")
+		if len(chunk) > kittyChunk || (i < len(sequences)-1 && len(chunk) != kittyChunk) {
+			t.Errorf("sequence %d carries %d bytes, want chunks of %d", i, len(chunk), kittyChunk)
+		}
+		payload.WriteString(chunk)
+	}
+
+	// the chunks join back into the image
+	data, err := base64.StdEncoding.DecodeString(payload.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for y := 0; y < 128; y++ {
+		for x := 0; x < 128; x++ {
+			if got, want := color.GrayModel.Convert(decoded.At(x, y)), color.GrayModel.Convert(img.At(x, y)); got != want {
+				t.Fatalf("pixel %d,%d = %v, want %v", x, y, got, want)
+			}
+		}
+	}
+}
+
+func TestDetectionReply(t *testing.T) {
+	tests := []struct {
+		name  string
+		seq   string
+		want  bool
+		kitty bool
+		sixel bool
+		cell  [2]int
+		done  bool
+	}{
+		{name: "kitty accepts the query", seq: "\033_Gi=31;OK\033\\", want: true, kitty: true, cell: defaultCell},
+		{name: "kitty refuses the query", seq: "\033_Gi=31;ENOTSUPPORTED:unsupported format\033\\", want: true, cell: defaultCell},
+		{name: "kitty reply to another image", seq: "\033_Gi=5;OK\033\\", cell: defaultCell},
+		{name: "cell size", seq: "\033[6;20;10t", want: true, cell: [2]int{10, 20}},
+		{name: "cell size of zero is ignored", seq: "\033[6;0;0t", want: true, cell: defaultCell},
+		{name: "cell size missing the width", seq: "\033[6;20t", cell: defaultCell},
+		{name: "other window reports", seq: "\033[8;24;80t", cell: defaultCell},
+		{name: "device attributes with sixel", seq: "\033[?62;4;22c", want: true, sixel: true, cell: defaultCell, done: true},
+		{name: "device attributes without sixel", seq: "\033[?64;1;14;22c", want: true, cell: defaultCell, done: true},
+		{name: "a key", seq: "\033[A", cell: defaultCell},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDetection()
+			if got := d.Reply(tt.seq); got != tt.want {
+				t.Errorf("Reply(%q) = %v, want %v", tt.seq, got, tt.want)
+			}
+			want := Detection{Kitty: tt.kitty, Sixel: tt.sixel, Cell: tt.cell, Done: tt.done}
+			if *d != want {
+				t.Errorf("after Reply(%q) detection = %+v, want %+v", tt.seq, *d, want)
+			}
+		})
+	}
+}
+
+func TestDetectionBest(t *testing.T) {
+	tests := []struct {
+		kitty, sixel bool
+		want         Protocol
+	}{
+		{false, false, None},
+		{false, true, Sixel},
+		{true, false, Kitty},
+		{true, true, Kitty},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("kitty=%v,sixel=%v", tt.kitty, tt.sixel), func(t *testing.T) {
+			d := Detection{Kitty: tt.kitty, Sixel: tt.sixel}
+			if got := d.Best(); got != tt.want {
+				t.Errorf("Best() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}